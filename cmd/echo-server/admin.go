@@ -0,0 +1,29 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// requireAdmin restricts next to clients presenting the ADMIN_TOKEN as a
+// bearer token. Admin endpoints are disabled while ADMIN_TOKEN is unset.
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := os.Getenv("ADMIN_TOKEN")
+		if token == "" {
+			writeError(w, http.StatusForbidden, "Admin endpoints are disabled; set ADMIN_TOKEN to enable them")
+			return
+		}
+
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="echo-server"`)
+			writeError(w, http.StatusUnauthorized, "Invalid or missing admin token")
+			return
+		}
+
+		next(w, r)
+	}
+}
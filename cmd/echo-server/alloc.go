@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// byteSizeUnits are the suffixes accepted by parseByteSize, longest first so
+// that "KB" is matched before "B".
+var byteSizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+	{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize parses a non-negative size such as "512", "64KB" or
+// "10MB". Units are powers of 1024 and case-insensitive.
+func parseByteSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if number, ok := strings.CutSuffix(v, unit.suffix); ok {
+			v, multiplier = strings.TrimSpace(number), unit.bytes
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 || n > (1<<62)/multiplier {
+		return 0, fmt.Errorf("%q is not a valid size", s)
+	}
+	return n * multiplier, nil
+}
+
+// maxAlloc returns the largest allocation a request may ask for with
+// ?alloc=, as configured by MAX_ALLOC. It is zero, disabling allocation,
+// when unset.
+func maxAlloc() (int64, error) {
+	v := os.Getenv("MAX_ALLOC")
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := parseByteSize(v)
+	if err != nil {
+		return 0, fmt.Errorf("MAX_ALLOC: %v", err)
+	}
+	return n, nil
+}
+
+// allocateRequestMemory allocates the amount of memory requested with the
+// "alloc" query parameter, to simulate a memory-heavy handler. Every page is
+// written so the memory is actually resident. The caller holds on to the
+// returned slice for the rest of the request, after which it is garbage
+// collected.
+//
+// It reports whether the request may proceed; when it returns false an error
+// response has already been written.
+func allocateRequestMemory(wr http.ResponseWriter, req *http.Request) ([]byte, bool) {
+	v, ok := req.URL.Query()["alloc"]
+	if !ok {
+		return nil, true
+	}
+
+	limit, _ := maxAlloc() // Validated at startup by validateConfig
+	if limit == 0 {
+		return nil, true
+	}
+
+	size, err := parseByteSize(v[0])
+	if err != nil {
+		writeError(wr, http.StatusBadRequest, fmt.Sprintf("Invalid alloc size %q", v[0]))
+		return nil, false
+	}
+	if size > limit {
+		writeError(wr, http.StatusBadRequest, fmt.Sprintf("Alloc size %d exceeds the maximum of %d bytes", size, limit))
+		return nil, false
+	}
+
+	fmt.Printf("%s | alloc | holding %d byte(s)\n", req.RemoteAddr, size)
+
+	held := make([]byte, size)
+	for i := 0; i < len(held); i += os.Getpagesize() {
+		held[i] = 1
+	}
+	return held, true
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestRequestAllocation verifies ?alloc= allocations are bounded and do not crash the server
+func TestRequestAllocation(t *testing.T) {
+	t.Setenv("MAX_ALLOC", "16MB")
+
+	tests := []struct {
+		name       string
+		alloc      string
+		wantStatus int
+	}{
+		{"Within cap", "10MB", http.StatusOK},
+		{"Plain bytes", "4096", http.StatusOK},
+		{"Over cap", "1GB", http.StatusBadRequest},
+		{"Invalid size", "lots", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(httpBaseURL + "/?alloc=" + tt.alloc)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	// The server is still healthy afterwards
+	resp, err := http.Get(httpBaseURL + "/health")
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	t.Log("TestRequestAllocation passed")
+}
+
+// TestParseByteSize verifies sizes with and without units are parsed
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"512", 512, false},
+		{"64KB", 64 << 10, false},
+		{"10mb", 10 << 20, false},
+		{"2 GiB", 2 << 30, false},
+		{"-1MB", 0, true},
+		{"MB", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseByteSize(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseByteSize(%q) = %d, %v; want %d, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	t.Log("TestParseByteSize passed")
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// maxBatchSize caps the number of sub-requests in a batch.
+const maxBatchSize = 20
+
+// maxBatchBody caps the size of a batch request body when MAX_BODY_BYTES is
+// not set.
+const maxBatchBody = 1 << 20
+
+// batchRequest is a sub-request of a batch.
+type batchRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+}
+
+// batchResult is the response to a sub-request of a batch.
+type batchResult struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers"`
+	Body    string              `json:"body"`
+}
+
+// validate checks that the sub-request can be served, defaulting its method
+// to GET. Long-lived WebSocket and SSE streams are rejected, as their
+// response would never be complete, and so are connection resets with
+// ?reset, as sub-requests have no connection of their own.
+func (b *batchRequest) validate() error {
+	if b.Method == "" {
+		b.Method = http.MethodGet
+	}
+	if !isToken(b.Method) {
+		return fmt.Errorf("invalid method %q", b.Method)
+	}
+	u, err := url.ParseRequestURI(b.Path)
+	if err != nil || !strings.HasPrefix(b.Path, "/") || strings.ContainsAny(b.Path, " \t") {
+		return fmt.Errorf("invalid path %q, must be an absolute path with an optional query", b.Path)
+	}
+	if u.Path == "/batch" {
+		return fmt.Errorf("batches cannot contain other batches")
+	}
+	if u.Query().Has("reset") {
+		return fmt.Errorf("connection resets cannot be batched")
+	}
+	header := make(http.Header, len(b.Headers))
+	for name, value := range b.Headers {
+		if !isToken(name) || strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid header %q", name)
+		}
+		header.Set(name, value)
+	}
+	if isLongLived(&http.Request{URL: u, Header: header}) {
+		return fmt.Errorf("long-lived WebSocket and SSE streams cannot be batched")
+	}
+	return nil
+}
+
+// batchHandler handles POST /batch, serving each sub-request of the JSON
+// array body with handler, as if it were sent on its own from the same
+// client, and returning the responses as a JSON array in the same order.
+// Sub-requests are served one after the other, or all at once with
+// ?concurrent=true.
+func batchHandler(handler http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		maxBytes, _ := envInt("MAX_BODY_BYTES") // Validated at startup by validateConfig
+		if maxBytes == 0 {
+			maxBytes = maxBatchBody
+		}
+
+		var batch []batchRequest
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, int64(maxBytes))).Decode(&batch)
+
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds the maximum of %d bytes", maxBytesErr.Limit))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid batch, expected a JSON array of requests: %v", err))
+			return
+		}
+		if len(batch) == 0 || len(batch) > maxBatchSize {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("A batch must have 1 to %d requests, got %d", maxBatchSize, len(batch)))
+			return
+		}
+		for i := range batch {
+			if err := batch[i].validate(); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("Request %d: %v", i, err))
+				return
+			}
+		}
+
+		concurrent := r.URL.Query().Get("concurrent") == "true"
+		fmt.Printf("%s | batch | %d request(s), concurrent: %t\n", r.RemoteAddr, len(batch), concurrent)
+
+		results := make([]batchResult, len(batch))
+		serve := func(i int) {
+			sub := batch[i]
+			req, _ := http.NewRequestWithContext(r.Context(), sub.Method, sub.Path, strings.NewReader(sub.Body)) // Validated above
+			req.RequestURI = sub.Path
+			req.Host = r.Host
+			req.RemoteAddr = r.RemoteAddr
+			req.TLS = r.TLS
+			for name, value := range sub.Headers {
+				req.Header.Set(name, value)
+			}
+
+			rec := newResponseBuffer()
+			defer func() {
+				if p := recover(); p != nil {
+					rec = subRequestPanic(req, p)
+				}
+				results[i] = batchResult{Status: rec.status(), Headers: rec.header, Body: rec.body.String()}
+			}()
+			handler.ServeHTTP(rec, req)
+		}
+
+		if concurrent {
+			var wg sync.WaitGroup
+			for i := range batch {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					serve(i)
+				}(i)
+			}
+			wg.Wait()
+		} else {
+			for i := range batch {
+				serve(i)
+			}
+		}
+
+		writeJSON(w, http.StatusOK, results)
+	}
+}
+
+// subRequestPanic returns the error response of a sub-request whose handler
+// panicked with p, so that one sub-request cannot fail the whole batch. A
+// deliberately aborted response, e.g. by RESET_RATE, gets a 502.
+func subRequestPanic(req *http.Request, p interface{}) *responseBuffer {
+	rec := newResponseBuffer()
+	if p == http.ErrAbortHandler {
+		fmt.Printf("%s | batch | %s %s aborted its response\n", req.RemoteAddr, req.Method, req.URL)
+		writeError(rec, http.StatusBadGateway, "Response aborted")
+		return rec
+	}
+
+	fmt.Fprintf(os.Stderr, "%s | panic | %s %s: %v\n%s", req.RemoteAddr, req.Method, req.URL, p, debug.Stack())
+	writeError(rec, http.StatusInternalServerError, "Internal server error")
+	return rec
+}
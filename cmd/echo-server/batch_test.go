@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestBatch verifies /batch serves each sub-request and returns their echoes in order
+func TestBatch(t *testing.T) {
+	post := func(t *testing.T, query, body string) (*http.Response, []batchResult) {
+		t.Helper()
+
+		resp, err := http.Post(httpBaseURL+"/batch"+query, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var results []batchResult
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+				t.Fatalf("failed to decode results: %v", err)
+			}
+		}
+		return resp, results
+	}
+
+	const batch = `[
+		{"method": "GET", "path": "/first?x=1", "headers": {"X-Custom": "one"}},
+		{"method": "POST", "path": "/second", "body": "second body"}
+	]`
+
+	for _, query := range []string{"", "?concurrent=true"} {
+		t.Run("Batch"+query, func(t *testing.T) {
+			resp, results := post(t, query, batch)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", resp.StatusCode)
+			}
+			if len(results) != 2 {
+				t.Fatalf("expected 2 results, got %d", len(results))
+			}
+
+			for i, want := range [][]string{
+				{"GET /first?x=1 HTTP/1.1", "X-Custom: one"},
+				{"POST /second HTTP/1.1", "second body"},
+			} {
+				if results[i].Status != http.StatusOK {
+					t.Errorf("result %d: expected status 200, got %d", i, results[i].Status)
+				}
+				for _, s := range want {
+					if !strings.Contains(results[i].Body, s) {
+						t.Errorf("result %d: expected body to contain %q, got: %s", i, s, results[i].Body)
+					}
+				}
+			}
+		})
+	}
+
+	for _, query := range []string{"", "?concurrent=true"} {
+		t.Run("Aborted sub-request"+query, func(t *testing.T) {
+			resp, results := post(t, query, `[{"path": "/bytes/10?fail-at=5"}, {"path": "/after"}]`)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", resp.StatusCode)
+			}
+			if len(results) != 2 {
+				t.Fatalf("expected 2 results, got %d", len(results))
+			}
+
+			if results[0].Status != http.StatusBadGateway {
+				t.Errorf("expected the aborted sub-request to get status 502, got %d", results[0].Status)
+			}
+			if results[1].Status != http.StatusOK || !strings.Contains(results[1].Body, "GET /after") {
+				t.Errorf("expected the next sub-request to be served, got status %d: %s", results[1].Status, results[1].Body)
+			}
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, body := range []string{
+			`{"path": "/"}`,
+			`[]`,
+			`[{"path": "relative"}]`,
+			`[{"method": "G T", "path": "/"}]`,
+			`[{"path": "/batch"}]`,
+			`[{"path": "/?reset=true"}]`,
+			`[{"path": "/.sse"}]`,
+			`[{"path": "/events/.sse?interval=1s"}]`,
+			`[{"path": "/", "headers": {"Connection": "Upgrade", "Upgrade": "websocket"}}]`,
+			`[{"path": "/", "headers": {"X-Bad": "a\r\nb"}}]`,
+			"[" + strings.Repeat(`{"path": "/"},`, maxBatchSize) + `{"path": "/"}]`,
+		} {
+			if resp, _ := post(t, "", body); resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("expected status 400 for %s, got %d", body, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Log("TestBatch passed")
+}
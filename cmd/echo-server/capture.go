@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// capturedRequestHeader is the response header carrying the id under which
+// an echo request was captured.
+const capturedRequestHeader = "X-Echo-Request-Id"
+
+// replayHeader marks replayed requests with the id of the captured request
+// they replay. Such requests are never captured themselves, so a replay can
+// never trigger another replay.
+const replayHeader = "X-Echo-Replay-Of"
+
+// maxCapturedBody caps the body size of captured requests. Requests with
+// larger bodies are echoed but not captured.
+const maxCapturedBody = 1 << 20
+
+// capturedRequest is an echo request kept for replay.
+type capturedRequest struct {
+	method     string
+	requestURI string
+	host       string
+	header     http.Header
+	body       []byte
+}
+
+// requestBuffer keeps the most recent echo requests, keyed by an increasing
+// id, so they can be replayed.
+type requestBuffer struct {
+	mu       sync.Mutex
+	nextID   int
+	order    []int
+	requests map[int]*capturedRequest
+}
+
+// capturedRequests holds the echo requests captured when CAPTURE_REQUESTS is
+// set.
+var capturedRequests = &requestBuffer{nextID: 1, requests: map[int]*capturedRequest{}}
+
+// add stores req, evicting the oldest requests beyond limit, and returns its
+// id.
+func (b *requestBuffer) add(req *capturedRequest, limit int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.requests[id] = req
+	b.order = append(b.order, id)
+
+	for len(b.order) > limit {
+		delete(b.requests, b.order[0])
+		b.order = b.order[1:]
+	}
+	return id
+}
+
+// get returns the captured request with the given id, if it is still kept.
+func (b *requestBuffer) get(id int) (*capturedRequest, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	req, ok := b.requests[id]
+	return req, ok
+}
+
+// captureRequest records req in the request buffer when CAPTURE_REQUESTS is
+// set to the number of requests to keep, and reports the id it was captured
+// under in the X-Echo-Request-Id response header. The body is buffered and
+// restored so it can still be echoed.
+func captureRequest(wr http.ResponseWriter, req *http.Request) {
+	limit, _ := envInt("CAPTURE_REQUESTS") // Validated at startup by validateConfig
+	if limit == 0 || req.Header.Get(replayHeader) != "" {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxCapturedBody+1))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+	if err != nil || len(body) > maxCapturedBody {
+		return
+	}
+
+	id := capturedRequests.add(&capturedRequest{
+		method:     req.Method,
+		requestURI: req.RequestURI,
+		host:       req.Host,
+		header:     req.Header.Clone(),
+		body:       body,
+	}, limit)
+	wr.Header().Set(capturedRequestHeader, strconv.Itoa(id))
+}
+
+// responseBuffer buffers the response to a request served internally, by a
+// replay or a batch.
+type responseBuffer struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+// newResponseBuffer returns an empty responseBuffer.
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: make(http.Header)}
+}
+
+func (rec *responseBuffer) Header() http.Header {
+	return rec.header
+}
+
+func (rec *responseBuffer) WriteHeader(code int) {
+	if rec.code == 0 {
+		rec.code = code
+	}
+}
+
+func (rec *responseBuffer) Write(p []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(p)
+}
+
+// Flush does nothing, as the whole response is buffered anyway, but lets
+// streaming handlers flush.
+func (rec *responseBuffer) Flush() {}
+
+// status returns the status code of the response, 200 if none was written.
+func (rec *responseBuffer) status() int {
+	if rec.code == 0 {
+		return http.StatusOK
+	}
+	return rec.code
+}
+
+// replayHandler handles POST /requests/{id}/replay, re-issuing the captured
+// request with the given id against handler and returning its response. The
+// replayed request carries an X-Echo-Replay-Of header and is not captured
+// again. Long-lived WebSocket and SSE requests are not replayed, as their
+// response would never be complete.
+func replayHandler(handler http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(replayHeader) != "" {
+			writeError(w, http.StatusLoopDetected, "Replayed requests cannot replay other requests")
+			return
+		}
+
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request id")
+			return
+		}
+
+		captured, ok := capturedRequests.get(id)
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("No captured request with id %d", id))
+			return
+		}
+
+		replay, err := http.NewRequestWithContext(r.Context(), captured.method, captured.requestURI, bytes.NewReader(captured.body))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Captured request %d cannot be replayed: %v", id, err))
+			return
+		}
+		replay.RequestURI = captured.requestURI
+		replay.Host = captured.host
+		replay.RemoteAddr = r.RemoteAddr
+		replay.TLS = r.TLS
+		replay.Header = captured.header.Clone()
+		replay.Header.Set(replayHeader, strconv.Itoa(id))
+
+		if isLongLived(replay) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Captured request %d is a long-lived WebSocket or SSE stream and cannot be replayed", id))
+			return
+		}
+
+		fmt.Printf("%s | replay | %s %s (captured request %d)\n", r.RemoteAddr, captured.method, captured.requestURI, id)
+
+		rec := newResponseBuffer()
+		handler.ServeHTTP(rec, replay)
+
+		for name, values := range rec.Header() {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(rec.status())
+		w.Write(rec.body.Bytes()) // nolint:errcheck
+	}
+}
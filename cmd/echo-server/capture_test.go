@@ -0,0 +1,110 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestRequestReplay verifies a captured echo request can be replayed by id
+func TestRequestReplay(t *testing.T) {
+	t.Setenv("CAPTURE_REQUESTS", "10")
+	t.Setenv("ADMIN_TOKEN", "secret")
+
+	resp, err := http.Post(httpBaseURL+"/captured?x=1", "text/plain", strings.NewReader("captured body"))
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	resp.Body.Close()
+
+	id := resp.Header.Get(capturedRequestHeader)
+	if id == "" {
+		t.Fatalf("expected %s header", capturedRequestHeader)
+	}
+
+	replay := func(t *testing.T, id, token string) (*http.Response, string) {
+		t.Helper()
+
+		req, err := http.NewRequest("POST", httpBaseURL+"/requests/"+id+"/replay", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+		return resp, string(body)
+	}
+
+	t.Run("Replayed", func(t *testing.T) {
+		resp, body := replay(t, id, "secret")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", resp.StatusCode, body)
+		}
+
+		for _, want := range []string{"POST /captured?x=1 HTTP/1.1", "captured body", "X-Echo-Replay-Of: " + id} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected replayed echo to contain %q, got: %s", want, body)
+			}
+		}
+
+		// Replays are not captured again
+		if v := resp.Header.Get(capturedRequestHeader); v != "" {
+			t.Errorf("expected replay not to be captured, got id %s", v)
+		}
+	})
+
+	t.Run("Long-lived stream", func(t *testing.T) {
+		t.Setenv("SSE_MAX_EVENTS", "1")
+
+		resp, err := http.Get(httpBaseURL + "/captured/.sse")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body) // nolint:errcheck
+		resp.Body.Close()
+
+		sseID := resp.Header.Get(capturedRequestHeader)
+		if sseID == "" {
+			t.Fatalf("expected %s header", capturedRequestHeader)
+		}
+
+		// The replay would otherwise stream until the client disconnects
+		t.Setenv("SSE_MAX_EVENTS", "")
+		if resp, body := replay(t, sseID, "secret"); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d: %s", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("Unknown id", func(t *testing.T) {
+		if resp, _ := replay(t, "999999", "secret"); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Wrong token", func(t *testing.T) {
+		if resp, _ := replay(t, id, "wrong"); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Admin disabled", func(t *testing.T) {
+		t.Setenv("ADMIN_TOKEN", "")
+		if resp, _ := replay(t, id, "secret"); resp.StatusCode != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Log("TestRequestReplay passed")
+}
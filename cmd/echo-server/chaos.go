@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// chaosStatusCodes are the server errors picked from when
+// CHAOS_ERROR_STATUS is "random".
+var chaosStatusCodes = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// chaosErrorRate returns the fraction of echo requests that should fail, as
+// configured by CHAOS_ERROR_RATE. It defaults to zero.
+func chaosErrorRate() (float64, error) {
+	return envFraction("CHAOS_ERROR_RATE")
+}
+
+// chaosStatus returns the status code of an injected failure. It is 500
+// unless CHAOS_ERROR_STATUS is "random", in which case a random 5xx is used.
+func chaosStatus() int {
+	if strings.EqualFold(os.Getenv("CHAOS_ERROR_STATUS"), "random") {
+		return chaosStatusCodes[rand.IntN(len(chaosStatusCodes))]
+	}
+	return http.StatusInternalServerError
+}
+
+// injectChaos randomly fails the request according to CHAOS_ERROR_RATE, or
+// the rate set through /admin/config. It reports whether a failure was
+// written, in which case the caller must not write anything else.
+func injectChaos(wr http.ResponseWriter, req *http.Request) bool {
+	rate := effectiveChaosErrorRate()
+	if rate == 0 || rand.Float64() >= rate {
+		return false
+	}
+
+	code := chaosStatus()
+	fmt.Printf("%s | chaos | injected %d for %s %s\n", req.RemoteAddr, code, req.Method, req.URL)
+
+	writeError(wr, code, fmt.Sprintf("Chaos error injected with status %d", code))
+	return true
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestChaosErrorRate verifies that echo requests fail according to CHAOS_ERROR_RATE
+func TestChaosErrorRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		rate       string
+		status     string
+		wantStatus func(code int) bool
+	}{
+		{"disabled", "0", "", func(code int) bool { return code == http.StatusOK }},
+		{"always fails", "1", "", func(code int) bool { return code == http.StatusInternalServerError }},
+		{"random 5xx", "1", "random", func(code int) bool { return code >= 500 && code <= 599 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CHAOS_ERROR_RATE", tt.rate)
+			t.Setenv("CHAOS_ERROR_STATUS", tt.status)
+
+			for i := 0; i < 5; i++ {
+				resp, err := http.Get(httpBaseURL + "/chaos")
+				if err != nil {
+					t.Fatalf("failed to make request: %v", err)
+				}
+				resp.Body.Close()
+
+				if !tt.wantStatus(resp.StatusCode) {
+					t.Errorf("unexpected status %d", resp.StatusCode)
+				}
+			}
+		})
+	}
+
+	t.Log("TestChaosErrorRate passed")
+}
+
+// TestChaosErrorRateValidation verifies invalid rates are rejected
+func TestChaosErrorRateValidation(t *testing.T) {
+	for _, v := range []string{"-0.1", "1.5", "abc"} {
+		t.Setenv("CHAOS_ERROR_RATE", v)
+		if _, err := chaosErrorRate(); err == nil {
+			t.Errorf("expected error for CHAOS_ERROR_RATE=%q", v)
+		}
+	}
+
+	t.Log("TestChaosErrorRateValidation passed")
+}
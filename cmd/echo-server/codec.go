@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// codec is an encoding supported by /encode and /decode.
+type codec struct {
+	encode func([]byte) string
+	decode func(string) ([]byte, error)
+}
+
+// codecs are the encodings supported by /encode and /decode, keyed by the
+// "type" query parameter.
+var codecs = map[string]codec{
+	"url": {
+		encode: func(b []byte) string { return url.QueryEscape(string(b)) },
+		decode: func(s string) ([]byte, error) {
+			decoded, err := url.QueryUnescape(s)
+			return []byte(decoded), err
+		},
+	},
+	"base64": {
+		encode: base64.StdEncoding.EncodeToString,
+		decode: func(s string) ([]byte, error) {
+			// Padding is optional
+			return base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+		},
+	},
+	"hex": {
+		encode: hex.EncodeToString,
+		decode: hex.DecodeString,
+	},
+}
+
+// codecParams returns the codec named by the "type" query parameter and the
+// "value" query parameter, or writes a 400 response and returns false.
+func codecParams(w http.ResponseWriter, r *http.Request) (codec, string, bool) {
+	query := r.URL.Query()
+
+	c, ok := codecs[query.Get("type")]
+	if !ok {
+		names := make([]string, 0, len(codecs))
+		for name := range codecs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid type %q (want %s)", query.Get("type"), strings.Join(names, ", ")))
+		return codec{}, "", false
+	}
+
+	value, ok := query["value"]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Missing value query parameter")
+		return codec{}, "", false
+	}
+	return c, value[0], true
+}
+
+// encodeHandler handles GET /encode?type=url|base64|hex&value=, returning
+// the value encoded as text/plain.
+func encodeHandler(w http.ResponseWriter, r *http.Request) {
+	c, value, ok := codecParams(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, c.encode([]byte(value))) // nolint:errcheck
+}
+
+// decodeHandler handles GET /decode?type=url|base64|hex&value=, returning
+// the decoded value as text/plain, or 400 if the value is malformed.
+func decodeHandler(w http.ResponseWriter, r *http.Request) {
+	c, value, ok := codecParams(w, r)
+	if !ok {
+		return
+	}
+
+	decoded, err := c.decode(value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s value", r.URL.Query().Get("type")))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(decoded) // nolint:errcheck
+}
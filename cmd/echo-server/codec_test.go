@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// TestEncodeDecode verifies /encode and /decode round-trip each type and reject invalid input
+func TestEncodeDecode(t *testing.T) {
+	get := func(t *testing.T, path string, params url.Values) (int, string) {
+		t.Helper()
+
+		resp, err := http.Get(httpBaseURL + path + "?" + params.Encode())
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	const value = "héllo wörld/?&=+"
+
+	tests := []struct {
+		typ     string
+		encoded string
+	}{
+		{"url", "h%C3%A9llo+w%C3%B6rld%2F%3F%26%3D%2B"},
+		{"base64", "aMOpbGxvIHfDtnJsZC8/Jj0r"},
+		{"hex", "68c3a96c6c6f2077c3b6726c642f3f263d2b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			code, encoded := get(t, "/encode", url.Values{"type": {tt.typ}, "value": {value}})
+			if code != http.StatusOK || encoded != tt.encoded {
+				t.Fatalf("expected 200 with %q, got %d with %q", tt.encoded, code, encoded)
+			}
+
+			code, decoded := get(t, "/decode", url.Values{"type": {tt.typ}, "value": {encoded}})
+			if code != http.StatusOK || decoded != value {
+				t.Errorf("expected 200 with %q, got %d with %q", value, code, decoded)
+			}
+		})
+	}
+
+	invalid := []struct {
+		name   string
+		path   string
+		params url.Values
+	}{
+		{"Unknown type", "/decode", url.Values{"type": {"rot13"}, "value": {"x"}}},
+		{"Missing value", "/encode", url.Values{"type": {"hex"}}},
+		{"Malformed url", "/decode", url.Values{"type": {"url"}, "value": {"%zz"}}},
+		{"Malformed base64", "/decode", url.Values{"type": {"base64"}, "value": {"!!!"}}},
+		{"Malformed hex", "/decode", url.Values{"type": {"hex"}, "value": {"abc"}}},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _ := get(t, tt.path, tt.params); code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", code)
+			}
+		})
+	}
+
+	t.Log("TestEncodeDecode passed")
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gorilla/websocket"
+)
+
+// Supported response compression algorithms, named after their
+// Content-Encoding tokens.
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+	encodingBrotli  = "br"
+)
+
+// compressionConfig holds the response compression settings.
+type compressionConfig struct {
+	// algorithms lists the enabled encodings in server preference order.
+	algorithms []string
+
+	// level is the compression level, or nil to use each algorithm's default.
+	level *int
+
+	// types lists the media types eligible for compression, which may be
+	// wildcards such as "text/*".
+	types []string
+
+	// minSize is the smallest response body, in bytes, that is compressed.
+	minSize int
+}
+
+// defaultCompressTypes are the media types compressed when COMPRESS_TYPES is
+// not set.
+var defaultCompressTypes = []string{"text/*", "application/json"}
+
+// defaultCompressMinSize is the smallest body compressed when
+// COMPRESS_MIN_SIZE is not set.
+const defaultCompressMinSize = 1024
+
+// loadCompressionConfig reads the compression settings from the environment.
+//
+// COMPRESSION_ALGO is a comma-separated list of encodings in preference order
+// (e.g. "br,gzip,deflate"). Compression is disabled when it is empty.
+// COMPRESSION_LEVEL optionally sets the level, validated per algorithm.
+// COMPRESS_TYPES is a comma-separated list of the media types to compress,
+// and COMPRESS_MIN_SIZE the smallest body to compress (e.g. "512" or "4KB").
+func loadCompressionConfig() (compressionConfig, error) {
+	cfg := compressionConfig{types: defaultCompressTypes, minSize: defaultCompressMinSize}
+
+	for _, algo := range strings.Split(os.Getenv("COMPRESSION_ALGO"), ",") {
+		algo = strings.ToLower(strings.TrimSpace(algo))
+		if algo == "" {
+			continue
+		}
+
+		switch algo {
+		case encodingGzip, encodingDeflate, encodingBrotli:
+			cfg.algorithms = append(cfg.algorithms, algo)
+		default:
+			return cfg, fmt.Errorf("COMPRESSION_ALGO: unsupported algorithm %q", algo)
+		}
+	}
+
+	if v := os.Getenv("COMPRESSION_LEVEL"); v != "" {
+		level, err := strconv.Atoi(v)
+		if err != nil {
+			return cfg, fmt.Errorf("COMPRESSION_LEVEL: %v", err)
+		}
+
+		for _, algo := range cfg.algorithms {
+			min, max := compressionLevelRange(algo)
+			if level < min || level > max {
+				return cfg, fmt.Errorf("COMPRESSION_LEVEL: %d is out of range for %s (%d-%d)", level, algo, min, max)
+			}
+		}
+
+		cfg.level = &level
+	}
+
+	if v := os.Getenv("COMPRESS_TYPES"); v != "" {
+		cfg.types = nil
+		for _, t := range strings.Split(v, ",") {
+			t = strings.ToLower(strings.TrimSpace(t))
+			major, minor, ok := strings.Cut(t, "/")
+			if !ok || !isToken(major) || !isToken(minor) {
+				return cfg, fmt.Errorf("COMPRESS_TYPES: %q is not a valid media type", t)
+			}
+			cfg.types = append(cfg.types, t)
+		}
+	}
+
+	if v := os.Getenv("COMPRESS_MIN_SIZE"); v != "" {
+		size, err := parseByteSize(v)
+		if err != nil || size > 1<<30 {
+			return cfg, fmt.Errorf("COMPRESS_MIN_SIZE: %q is not a valid size", v)
+		}
+		cfg.minSize = int(size)
+	}
+
+	return cfg, nil
+}
+
+// compressible reports whether responses with the given Content-Type are
+// eligible for compression.
+func (cfg compressionConfig) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	major, _, _ := strings.Cut(mediaType, "/")
+
+	for _, t := range cfg.types {
+		if t == "*/*" || t == mediaType || t == major+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// compressionLevelRange returns the valid compression levels for algo.
+func compressionLevelRange(algo string) (int, int) {
+	if algo == encodingBrotli {
+		return brotli.BestSpeed, brotli.BestCompression
+	}
+	return gzip.DefaultCompression, gzip.BestCompression
+}
+
+// compressMiddleware compresses responses using the best encoding accepted by
+// the client among the configured algorithms.
+func compressMiddleware(cfg compressionConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(cfg.algorithms) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Upgraded connections are hijacked and never use the response body.
+			if websocket.IsWebSocketUpgrade(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), cfg.algorithms)
+			if encoding == "" {
+				w.Header().Add("Vary", "Accept-Encoding")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressResponseWriter{
+				ResponseWriter: w,
+				encoding:       encoding,
+				cfg:            cfg,
+			}
+			defer cw.Close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks the encoding to use given the client's
+// Accept-Encoding header. The highest client q-value wins; ties are broken by
+// the server preference order. It returns an empty string if none of the
+// algorithms is acceptable.
+func negotiateEncoding(acceptEncoding string, algorithms []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				q = parsed
+			}
+		}
+		qualities[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, algo := range algorithms {
+		q, ok := qualities[algo]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = algo, q
+		}
+	}
+
+	return best
+}
+
+// compressResponseWriter compresses response bodies of eligible types and
+// sizes. Until it has seen COMPRESS_MIN_SIZE bytes of an eligible body, it
+// holds back the status line and the body, so that smaller bodies can still
+// be sent uncompressed.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding    string
+	cfg         compressionConfig
+	writer      io.WriteCloser
+	wroteHeader bool
+
+	// pending is set while the status code and body are held back, until
+	// the body is known to be large enough to compress.
+	pending bool
+	code    int
+	buf     []byte
+}
+
+func (w *compressResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	h := w.Header()
+	h.Add("Vary", "Accept-Encoding")
+
+	if h.Get("Content-Encoding") == "" &&
+		code >= http.StatusOK &&
+		code != http.StatusNoContent &&
+		code != http.StatusNotModified &&
+		w.cfg.compressible(h.Get("Content-Type")) {
+		w.code = code
+		if w.cfg.minSize > 0 {
+			w.pending = true
+			return
+		}
+		w.startCompression()
+		return
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// startCompression sends the held-back status line with the compression
+// headers and compresses the rest of the body.
+func (w *compressResponseWriter) startCompression() {
+	h := w.Header()
+	h.Set("Content-Encoding", w.encoding)
+	h.Del("Content-Length")
+	w.writer = newCompressor(w.ResponseWriter, w.encoding, w.cfg.level)
+
+	w.ResponseWriter.WriteHeader(w.code)
+}
+
+// release ends the holding back of the status line and body, compressing
+// the body from now on if compress is set.
+func (w *compressResponseWriter) release(compress bool) error {
+	w.pending = false
+	buf := w.buf
+	w.buf = nil
+
+	if compress {
+		w.startCompression()
+		_, err := w.writer.Write(buf)
+		return err
+	}
+
+	w.ResponseWriter.WriteHeader(w.code)
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *compressResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		// Sniff the content type from the uncompressed bytes, as the server
+		// would otherwise sniff the compressed output.
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.pending {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.cfg.minSize {
+			return len(b), nil
+		}
+		return len(b), w.release(true)
+	}
+
+	if w.writer == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.writer.Write(b)
+}
+
+// Flush flushes any buffered compressed data to the client, so that streaming
+// responses such as SSE keep working. A body still held back is compressed
+// from then on, as a streamed response's final size is unknown.
+func (w *compressResponseWriter) Flush() {
+	if w.pending {
+		w.release(true) // nolint:errcheck
+	}
+	if f, ok := w.writer.(interface{ Flush() error }); ok {
+		f.Flush() // nolint:errcheck
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close sends a body too small to compress as is, or finishes the compressed
+// stream.
+func (w *compressResponseWriter) Close() error {
+	if w.pending {
+		return w.release(false)
+	}
+	if w.writer == nil {
+		return nil
+	}
+	return w.writer.Close()
+}
+
+// Unwrap returns the underlying writer for use by http.ResponseController.
+func (w *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// newCompressor returns a writer compressing into w with the given encoding.
+func newCompressor(w io.Writer, encoding string, level *int) io.WriteCloser {
+	switch encoding {
+	case encodingBrotli:
+		if level == nil {
+			return brotli.NewWriter(w)
+		}
+		return brotli.NewWriterLevel(w, *level)
+	case encodingDeflate:
+		if level == nil {
+			return zlib.NewWriter(w)
+		}
+		zw, _ := zlib.NewWriterLevel(w, *level)
+		return zw
+	default:
+		if level == nil {
+			return gzip.NewWriter(w)
+		}
+		gw, _ := gzip.NewWriterLevel(w, *level)
+		return gw
+	}
+}
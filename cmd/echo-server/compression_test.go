@@ -0,0 +1,221 @@
+package main
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+// TestCompressionRoundTrip verifies each algorithm compresses the echo response
+func TestCompressionRoundTrip(t *testing.T) {
+	tests := []struct {
+		algo      string
+		level     string
+		newReader func(io.Reader) (io.Reader, error)
+	}{
+		{
+			algo:  "gzip",
+			level: "9",
+			newReader: func(r io.Reader) (io.Reader, error) {
+				return gzip.NewReader(r)
+			},
+		},
+		{
+			algo:  "deflate",
+			level: "1",
+			newReader: func(r io.Reader) (io.Reader, error) {
+				return zlib.NewReader(r)
+			},
+		},
+		{
+			algo:  "br",
+			level: "11",
+			newReader: func(r io.Reader) (io.Reader, error) {
+				return brotli.NewReader(r), nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algo, func(t *testing.T) {
+			t.Setenv("COMPRESSION_ALGO", tt.algo)
+			t.Setenv("COMPRESSION_LEVEL", tt.level)
+
+			server := httptest.NewServer(createRouter())
+			defer server.Close()
+
+			req, err := http.NewRequest("POST", server.URL+"/compressed", strings.NewReader(strings.Repeat("compress this body ", 100)))
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			req.Header.Set("Accept-Encoding", tt.algo)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if ce := resp.Header.Get("Content-Encoding"); ce != tt.algo {
+				t.Fatalf("expected Content-Encoding %s, got %q", tt.algo, ce)
+			}
+
+			reader, err := tt.newReader(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to create decompressor: %v", err)
+			}
+
+			body, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("failed to decompress response: %v", err)
+			}
+
+			if !strings.Contains(string(body), "POST /compressed HTTP") {
+				t.Errorf("decompressed body doesn't contain request line: %s", body)
+			}
+
+			if !strings.Contains(string(body), "compress this body") {
+				t.Errorf("decompressed body doesn't contain request body: %s", body)
+			}
+		})
+	}
+
+	t.Log("TestCompressionRoundTrip passed")
+}
+
+// TestCompressionEligibility verifies only bodies of COMPRESS_TYPES of at least COMPRESS_MIN_SIZE are compressed
+func TestCompressionEligibility(t *testing.T) {
+	t.Setenv("COMPRESSION_ALGO", "gzip")
+	t.Setenv("COMPRESS_TYPES", "text/*, application/json")
+	t.Setenv("COMPRESS_MIN_SIZE", "1KB")
+
+	server := httptest.NewServer(createRouter())
+	defer server.Close()
+
+	large := strings.Repeat("x", 2048)
+
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		wantEncoding string
+	}{
+		{"Eligible type", "text/plain", large, "gzip"},
+		{"Eligible type with parameters", "application/json; charset=utf-8", large, "gzip"},
+		{"Ineligible type", "image/png", large, ""},
+		{"Below minimum size", "text/plain", "tiny", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := server.URL + "/compressible?content-type=" + strings.ReplaceAll(tt.contentType, " ", "%20")
+			req, err := http.NewRequest("POST", url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			req.Header.Set("Accept-Encoding", "gzip")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if ce := resp.Header.Get("Content-Encoding"); ce != tt.wantEncoding {
+				t.Fatalf("expected Content-Encoding %q, got %q", tt.wantEncoding, ce)
+			}
+
+			reader := io.Reader(resp.Body)
+			if tt.wantEncoding == "gzip" {
+				if reader, err = gzip.NewReader(resp.Body); err != nil {
+					t.Fatalf("failed to create decompressor: %v", err)
+				}
+			}
+
+			body, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			if !strings.Contains(string(body), tt.body) {
+				t.Errorf("expected the echo to contain the request body, got %d bytes", len(body))
+			}
+		})
+	}
+
+	t.Run("Invalid settings", func(t *testing.T) {
+		for name, value := range map[string]string{"COMPRESS_TYPES": "text", "COMPRESS_MIN_SIZE": "big"} {
+			t.Setenv(name, value)
+			if _, err := loadCompressionConfig(); err == nil {
+				t.Errorf("expected error for %s=%q", name, value)
+			}
+			t.Setenv(name, "")
+		}
+	})
+
+	t.Log("TestCompressionEligibility passed")
+}
+
+// TestNegotiateEncoding verifies Accept-Encoding q-values and preference order
+func TestNegotiateEncoding(t *testing.T) {
+	algorithms := []string{"br", "gzip", "deflate"}
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		want           string
+	}{
+		{"empty header", "", ""},
+		{"single match", "gzip", "gzip"},
+		{"server preference on tie", "gzip, br", "br"},
+		{"client q-value wins", "br;q=0.5, gzip;q=0.8", "gzip"},
+		{"q=0 is not acceptable", "br;q=0, deflate", "deflate"},
+		{"wildcard", "*", "br"},
+		{"unsupported only", "zstd", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiateEncoding(tt.acceptEncoding, algorithms); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Log("TestNegotiateEncoding passed")
+}
+
+// TestCompressionConfigValidation verifies level ranges are validated per algorithm
+func TestCompressionConfigValidation(t *testing.T) {
+	tests := []struct {
+		algo    string
+		level   string
+		wantErr bool
+	}{
+		{"gzip", "9", false},
+		{"gzip", "10", true},
+		{"br", "11", false},
+		{"br", "12", true},
+		{"deflate", "abc", true},
+		{"zstd", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algo+"/"+tt.level, func(t *testing.T) {
+			t.Setenv("COMPRESSION_ALGO", tt.algo)
+			t.Setenv("COMPRESSION_LEVEL", tt.level)
+
+			_, err := loadCompressionConfig()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Log("TestCompressionConfigValidation passed")
+}
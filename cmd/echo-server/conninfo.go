@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// connInfo tracks a client connection for SEND_CONN_INFO.
+//
+// Requests are associated with their connection through the request context:
+// the server's ConnContext hook stores a connInfo in the base context of
+// every accepted connection, which all requests on it inherit. This works for
+// HTTP/1.1 keep-alive connections and for HTTP/2, where many requests share
+// one connection.
+type connInfo struct {
+	openedAt time.Time
+	requests atomic.Int64
+
+	// h2cUpgraded is set once an h2c upgrade request is seen on the
+	// connection, see negotiatedProtocol.
+	h2cUpgraded atomic.Bool
+}
+
+// connInfoKey is the context key of the connection's connInfo.
+type connInfoKey struct{}
+
+// connContext is an http.Server.ConnContext hook that starts tracking a
+// newly accepted connection.
+func connContext(ctx context.Context, _ net.Conn) context.Context {
+	return context.WithValue(ctx, connInfoKey{}, &connInfo{openedAt: time.Now()})
+}
+
+// countConnRequests counts every request served on a tracked connection.
+//
+// When MAX_REQUESTS_PER_CONN is set, the response to the last request
+// allowed on an HTTP/1.x connection carries "Connection: close", so the
+// server closes the connection and the client has to open a new one.
+func countConnRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if info, ok := r.Context().Value(connInfoKey{}).(*connInfo); ok {
+			n := info.requests.Add(1)
+
+			limit, _ := envInt("MAX_REQUESTS_PER_CONN") // Validated at startup by validateConfig
+			if limit > 0 && n >= int64(limit) && r.ProtoMajor == 1 {
+				w.Header().Set("Connection", "close")
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeConnInfo writes the age of the request's connection and the number
+// of requests served on it so far, including this one, if it is tracked.
+func writeConnInfo(w io.Writer, req *http.Request) {
+	info, ok := req.Context().Value(connInfoKey{}).(*connInfo)
+	if !ok {
+		return
+	}
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Connection")
+	fmt.Fprintf(w, "  Age: %s\n", time.Since(info.openedAt).Round(time.Microsecond))
+	fmt.Fprintf(w, "  Requests: %d\n", info.requests.Load())
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestConnInfo verifies requests on a reused connection report an increasing count and age
+func TestConnInfo(t *testing.T) {
+	t.Setenv("SEND_CONN_INFO", "true")
+
+	// A dedicated transport, so both requests share a fresh connection
+	client := &http.Client{Transport: &http.Transport{}}
+	defer client.CloseIdleConnections()
+
+	pattern := regexp.MustCompile(`\nConnection\n  Age: (\S+)\n  Requests: (\d+)\n`)
+
+	var lastAge time.Duration
+	for i := 1; i <= 2; i++ {
+		resp, err := client.Get(httpBaseURL + "/conn-info")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		match := pattern.FindStringSubmatch(string(body))
+		if match == nil {
+			t.Fatalf("expected connection info, got: %s", body)
+		}
+
+		age, err := time.ParseDuration(match[1])
+		if err != nil {
+			t.Fatalf("failed to parse age %q: %v", match[1], err)
+		}
+		if age < lastAge {
+			t.Errorf("expected the connection age to increase, got %s after %s", age, lastAge)
+		}
+		lastAge = age
+
+		if requests, _ := strconv.Atoi(match[2]); requests != i {
+			t.Errorf("expected request count %d, got %d", i, requests)
+		}
+	}
+
+	t.Log("TestConnInfo passed")
+}
+
+// TestMaxRequestsPerConn verifies the server closes a keep-alive connection after MAX_REQUESTS_PER_CONN requests
+func TestMaxRequestsPerConn(t *testing.T) {
+	t.Setenv("MAX_REQUESTS_PER_CONN", "3")
+
+	transport := &http.Transport{}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport}
+
+	var reused []bool
+	for i := 0; i < 4; i++ {
+		var wasReused bool
+		trace := &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) { wasReused = info.Reused },
+		}
+
+		req, err := http.NewRequest("GET", httpBaseURL+"/keepalive", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request %d: %v", i+1, err)
+		}
+		io.Copy(io.Discard, resp.Body) // nolint:errcheck
+		resp.Body.Close()
+
+		if wantClose := i == 2; resp.Close != wantClose {
+			t.Errorf("request %d: expected Connection: close to be %v, got %v", i+1, wantClose, resp.Close)
+		}
+		reused = append(reused, wasReused)
+	}
+
+	// The first connection serves three requests, the fourth needs a new one
+	want := []bool{false, true, true, false}
+	for i := range want {
+		if reused[i] != want[i] {
+			t.Errorf("expected connection reuse %v, got %v", want, reused)
+			break
+		}
+	}
+
+	t.Log("TestMaxRequestsPerConn passed")
+}
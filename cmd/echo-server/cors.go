@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// corsAllowedOrigins returns the origins allowed by the CORS policy, as
+// configured by the comma-separated CORS_ALLOW_ORIGIN. It defaults to "*",
+// which allows any origin.
+func corsAllowedOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("CORS_ALLOW_ORIGIN"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	if len(origins) == 0 {
+		return []string{"*"}
+	}
+	return origins
+}
+
+// corsOriginAllowed reports whether origin passes the CORS policy. Origins
+// are compared case-insensitively.
+func corsOriginAllowed(origin string) bool {
+	for _, allowed := range corsAllowedOrigins() {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// setCORSHeaders sets Access-Control-Allow-Origin on the response according
+// to the CORS policy: "*" when any origin is allowed, the request's Origin
+// when it is one of the allowed origins, and nothing otherwise, so browsers
+// block the response.
+func setCORSHeaders(w http.ResponseWriter, req *http.Request) {
+	origins := corsAllowedOrigins()
+	if slices.Contains(origins, "*") {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	// The header depends on the Origin, so caches must key on it
+	w.Header().Add("Vary", "Origin")
+
+	if origin := req.Header.Get("Origin"); origin != "" && corsOriginAllowed(origin) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+}
+
+// writeOriginInfo writes the request's Origin and Referer headers to w,
+// along with whether the Origin passes the CORS policy. Nothing is written
+// when neither header is present.
+func writeOriginInfo(w io.Writer, req *http.Request) {
+	origin := req.Header.Get("Origin")
+	referer := req.Header.Get("Referer")
+
+	if origin == "" && referer == "" {
+		return
+	}
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Origin")
+	fmt.Fprintf(w, "  Origin: %s\n", origin)
+	fmt.Fprintf(w, "  Referer: %s\n", referer)
+
+	policy := strings.Join(corsAllowedOrigins(), ", ")
+	switch {
+	case origin == "":
+		fmt.Fprintf(w, "  CORS: no Origin header (policy: %s)\n", policy)
+	case corsOriginAllowed(origin):
+		fmt.Fprintf(w, "  CORS: allowed (policy: %s)\n", policy)
+	default:
+		fmt.Fprintf(w, "  CORS: disallowed (policy: %s)\n", policy)
+	}
+}
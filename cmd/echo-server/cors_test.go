@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestOriginInfo verifies the Origin section reports the CORS policy decision
+func TestOriginInfo(t *testing.T) {
+	t.Setenv("SEND_ORIGIN_INFO", "true")
+	t.Setenv("CORS_ALLOW_ORIGIN", "https://app.example, https://admin.example")
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    []string
+		wantNot []string
+	}{
+		{
+			name:    "Allowed origin",
+			headers: map[string]string{"Origin": "https://APP.example"},
+			want:    []string{"\nOrigin\n", "  Origin: https://APP.example\n", "  CORS: allowed (policy: https://app.example, https://admin.example)"},
+		},
+		{
+			name: "Disallowed origin",
+			headers: map[string]string{
+				"Origin":  "https://evil.example",
+				"Referer": "https://evil.example/page",
+			},
+			want: []string{"  Referer: https://evil.example/page\n", "  CORS: disallowed (policy: https://app.example, https://admin.example)"},
+		},
+		{
+			name:    "Referer only",
+			headers: map[string]string{"Referer": "https://app.example/"},
+			want:    []string{"  CORS: no Origin header"},
+		},
+		{
+			name:    "No headers",
+			wantNot: []string{"\nOrigin\n", "CORS:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", httpBaseURL+"/origin", nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("expected response to contain %q, got: %s", want, body)
+				}
+			}
+			for _, notWant := range tt.wantNot {
+				if strings.Contains(string(body), notWant) {
+					t.Errorf("expected response not to contain %q, got: %s", notWant, body)
+				}
+			}
+		})
+	}
+
+	t.Log("TestOriginInfo passed")
+}
+
+// TestSSECORS verifies SSE streams honor the CORS policy
+func TestSSECORS(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    string
+		origin    string
+		wantAllow string
+	}{
+		{"Default policy", "", "https://any.example", "*"},
+		{"Allowed origin", "https://app.example", "https://app.example", "https://app.example"},
+		{"Disallowed origin", "https://app.example", "https://evil.example", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CORS_ALLOW_ORIGIN", tt.policy)
+			t.Setenv("SSE_MAX_EVENTS", "1")
+
+			req, err := http.NewRequest("GET", httpBaseURL+"/cors/.sse", nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			req.Header.Set("Origin", tt.origin)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if allow := resp.Header.Get("Access-Control-Allow-Origin"); allow != tt.wantAllow {
+				t.Errorf("expected Access-Control-Allow-Origin %q, got %q", tt.wantAllow, allow)
+			}
+		})
+	}
+
+	t.Log("TestSSECORS passed")
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// requestCostHeader is the request header carrying the cost of a request,
+// charged against the client's budget. Requests without it cost 1.
+const requestCostHeader = "X-Request-Cost"
+
+// requestCostRemainingHeader is the response header reporting the budget the
+// client has left after the request.
+const requestCostRemainingHeader = "X-Request-Cost-Remaining"
+
+// defaultRequestCostWindow is the time a spent budget takes to refill when
+// REQUEST_COST_WINDOW is not set.
+const defaultRequestCostWindow = time.Minute
+
+// maxCostClients caps the number of client budgets kept. Once reached,
+// budgets that have fully refilled are forgotten.
+const maxCostClients = 10000
+
+// costBudget is the token bucket of one client.
+type costBudget struct {
+	tokens  float64
+	updated time.Time
+}
+
+// costLimiter charges request costs against a token bucket per client IP.
+// Each bucket holds up to budget tokens and refills continuously, taking
+// window to refill from empty.
+type costLimiter struct {
+	mu      sync.Mutex
+	budgets map[string]*costBudget
+}
+
+// requestBudgets holds the client budgets used when REQUEST_COST_BUDGET is
+// set.
+var requestBudgets = &costLimiter{budgets: map[string]*costBudget{}}
+
+// charge takes cost tokens from the bucket of ip. It returns the tokens left
+// and true on success, or the time until enough tokens are available and
+// false when the budget is exhausted.
+func (l *costLimiter) charge(ip string, cost, budget int, window time.Duration) (int, time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	rate := float64(budget) / window.Seconds() // tokens per second
+
+	b, ok := l.budgets[ip]
+	if !ok {
+		if len(l.budgets) >= maxCostClients {
+			l.forgetRefilled(now, float64(budget), rate)
+		}
+		b = &costBudget{tokens: float64(budget), updated: now}
+		l.budgets[ip] = b
+	}
+
+	b.tokens = math.Min(float64(budget), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	if float64(cost) > b.tokens {
+		wait := time.Duration((float64(cost) - b.tokens) / rate * float64(time.Second))
+		return int(b.tokens), wait, false
+	}
+	b.tokens -= float64(cost)
+	return int(b.tokens), 0, true
+}
+
+// forgetRefilled drops the budgets that have refilled to budget by now, as
+// they are indistinguishable from new clients. l.mu must be held.
+func (l *costLimiter) forgetRefilled(now time.Time, budget, rate float64) {
+	for ip, b := range l.budgets {
+		if b.tokens+now.Sub(b.updated).Seconds()*rate >= budget {
+			delete(l.budgets, ip)
+		}
+	}
+}
+
+// requestCost returns the cost of req from its X-Request-Cost header, which
+// must be a positive integer. It defaults to 1.
+func requestCost(req *http.Request) (int, error) {
+	v := req.Header.Get(requestCostHeader)
+	if v == "" {
+		return 1, nil
+	}
+
+	cost, err := strconv.Atoi(v)
+	if err != nil || cost < 1 {
+		return 0, fmt.Errorf("Invalid %s %q, must be a positive integer", requestCostHeader, v)
+	}
+	return cost, nil
+}
+
+// requestCostMiddleware simulates cost-based rate limiting. When
+// REQUEST_COST_BUDGET is set, each client IP, as reported by
+// accountingClientIP, may spend that many cost units per REQUEST_COST_WINDOW,
+// and requests beyond its budget get a 429 with Retry-After. Requests costing
+// more than the whole budget can never succeed, so they get a 400. When
+// REQUEST_COST_DELAY is set, requests are also delayed by that much per cost
+// unit, up to MAX_DELAY.
+func requestCostMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Validated at startup by validateConfig
+		budget, _ := envInt("REQUEST_COST_BUDGET")
+		perUnit, _ := envDuration("REQUEST_COST_DELAY")
+		if budget == 0 && perUnit == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cost, err := requestCost(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if budget > 0 {
+			if cost > budget {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("Request cost %d exceeds the budget of %d", cost, budget))
+				return
+			}
+
+			window, _ := envDuration("REQUEST_COST_WINDOW")
+			if window == 0 {
+				window = defaultRequestCostWindow
+			}
+
+			remaining, wait, ok := requestBudgets.charge(accountingClientIP(r), cost, budget, window)
+			w.Header().Set(requestCostRemainingHeader, strconv.Itoa(remaining))
+			if !ok {
+				fmt.Printf("%s | cost | %d exceeds the remaining budget of %d\n", r.RemoteAddr, cost, remaining)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeError(w, http.StatusTooManyRequests, fmt.Sprintf("Request cost %d exceeds the remaining budget of %d", cost, remaining))
+				return
+			}
+		}
+
+		if perUnit > 0 {
+			cfg, _ := loadDelayConfig()
+			delay := cfg.max
+			if int64(cost) < int64(cfg.max/perUnit) {
+				delay = time.Duration(cost) * perUnit
+			}
+			fmt.Printf("%s | cost | delaying %s for a cost of %d\n", r.RemoteAddr, delay, cost)
+
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestRequestCost verifies request costs are charged against a per-client budget and 429 is returned once it is exhausted
+func TestRequestCost(t *testing.T) {
+	t.Setenv("REQUEST_COST_BUDGET", "10")
+	t.Setenv("REQUEST_COST_WINDOW", "1h")
+
+	// Clients are told apart by X-Forwarded-For, which the test client is
+	// trusted to set
+	t.Setenv("TRUST_PROXY", "127.0.0.1,::1")
+
+	get := func(t *testing.T, client, cost string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest("GET", httpBaseURL+"/costly", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("X-Forwarded-For", client)
+		if cost != "" {
+			req.Header.Set(requestCostHeader, cost)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("Budget exhausted", func(t *testing.T) {
+		for i, want := range []struct {
+			cost      string
+			status    int
+			remaining string
+		}{
+			{"4", http.StatusOK, "6"},
+			{"", http.StatusOK, "5"},
+			{"5", http.StatusOK, "0"},
+			{"1", http.StatusTooManyRequests, "0"},
+		} {
+			resp := get(t, "203.0.113.24", want.cost)
+			if resp.StatusCode != want.status {
+				t.Errorf("request %d: expected status %d, got %d", i+1, want.status, resp.StatusCode)
+			}
+			if got := resp.Header.Get(requestCostRemainingHeader); got != want.remaining {
+				t.Errorf("request %d: expected %s %s, got %q", i+1, requestCostRemainingHeader, want.remaining, got)
+			}
+		}
+
+		// A 1h window refills 10 tokens at one per 6 minutes
+		resp := get(t, "203.0.113.24", "1")
+		if retry, _ := strconv.Atoi(resp.Header.Get("Retry-After")); retry < 300 || retry > 360 {
+			t.Errorf("expected Retry-After of about 360s, got %q", resp.Header.Get("Retry-After"))
+		}
+	})
+
+	t.Run("Per client", func(t *testing.T) {
+		if resp := get(t, "203.0.113.25", "10"); resp.StatusCode != http.StatusOK {
+			t.Errorf("expected another client to have its own budget, got status %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Invalid cost", func(t *testing.T) {
+		if resp := get(t, "203.0.113.26", "-1"); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Cost over budget", func(t *testing.T) {
+		resp := get(t, "203.0.113.28", "11")
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", resp.StatusCode)
+		}
+		if resp.Header.Get("Retry-After") != "" {
+			t.Errorf("expected no Retry-After, got %q", resp.Header.Get("Retry-After"))
+		}
+	})
+
+	t.Run("Untrusted proxy", func(t *testing.T) {
+		t.Setenv("TRUST_PROXY", "false")
+
+		// Both requests are charged to the peer, whatever X-Forwarded-For says
+		if resp := get(t, "198.51.100.1", "10"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		if resp := get(t, "198.51.100.2", "1"); resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("expected a spoofed X-Forwarded-For not to reset the budget, got status %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Default TRUST_PROXY", func(t *testing.T) {
+		t.Setenv("TRUST_PROXY", "")
+
+		// Start from fresh budgets, as earlier tests spent the peer's
+		saved := requestBudgets
+		requestBudgets = &costLimiter{budgets: map[string]*costBudget{}}
+		defer func() { requestBudgets = saved }()
+
+		// Rotating X-Forwarded-For does not buy a fresh budget, as both
+		// requests are charged to the peer
+		if resp := get(t, "198.51.100.3", "10"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		if resp := get(t, "198.51.100.4", "1"); resp.StatusCode != http.StatusTooManyRequests {
+			t.Errorf("expected a rotated X-Forwarded-For to be ignored, got status %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Proportional delay", func(t *testing.T) {
+		t.Setenv("REQUEST_COST_BUDGET", "")
+		t.Setenv("REQUEST_COST_DELAY", "50ms")
+
+		start := time.Now()
+		if resp := get(t, "203.0.113.27", "4"); resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+		if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+			t.Errorf("expected a delay of 200ms, took %s", elapsed)
+		}
+	})
+
+	t.Log("TestRequestCost passed")
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// maxDecodedBody caps the decompressed size of an echoed request body, so a
+// small compressed body cannot expand without bound.
+const maxDecodedBody = 10 << 20
+
+// decodeRequestBody returns body decompressed according to the request's
+// Content-Encoding when DECODE_REQUEST_ENCODING is set, together with a note
+// describing what was done. It returns body unchanged with an empty note when
+// there is nothing to decode, and unchanged with a note explaining why when
+// decoding fails.
+func decodeRequestBody(req *http.Request, body []byte) ([]byte, string) {
+	encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" || len(body) == 0 || !envBool("DECODE_REQUEST_ENCODING") {
+		return body, ""
+	}
+
+	var r io.Reader
+	var err error
+
+	switch encoding {
+	case encodingGzip, "x-gzip":
+		r, err = gzip.NewReader(bytes.NewReader(body))
+	case encodingDeflate:
+		r, err = zlib.NewReader(bytes.NewReader(body))
+	case encodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return body, fmt.Sprintf("(unsupported Content-Encoding %q, raw bytes follow)", encoding)
+	}
+
+	var decoded []byte
+	if err == nil {
+		decoded, err = io.ReadAll(io.LimitReader(r, maxDecodedBody+1))
+	}
+	if err == nil && len(decoded) > maxDecodedBody {
+		err = fmt.Errorf("decoded body exceeds %d bytes", maxDecodedBody)
+	}
+	if err != nil {
+		return body, fmt.Sprintf("(failed to decode %s body: %v, raw bytes follow)", encoding, err)
+	}
+
+	return decoded, fmt.Sprintf("(decoded from %s, %d bytes on the wire)", encoding, len(body))
+}
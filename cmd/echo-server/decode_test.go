@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestDecodeRequestEncoding verifies compressed request bodies are echoed decompressed
+func TestDecodeRequestEncoding(t *testing.T) {
+	const content = "hello, compressed world"
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(content)) // nolint:errcheck
+	gz.Close()
+
+	tests := []struct {
+		name    string
+		enabled string
+		body    []byte
+		want    []string
+	}{
+		{"Decoded", "true", compressed.Bytes(), []string{"(decoded from gzip, ", content}},
+		{"Corrupt body", "true", []byte("not gzip"), []string{"(failed to decode gzip body: ", "not gzip"}},
+		{"Disabled", "", compressed.Bytes(), []string{string(compressed.Bytes())}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DECODE_REQUEST_ENCODING", tt.enabled)
+
+			req, err := http.NewRequest("POST", httpBaseURL+"/", bytes.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Encoding", "gzip")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("expected response to contain %q, got: %q", want, body)
+				}
+			}
+		})
+	}
+
+	t.Log("TestDecodeRequestEncoding passed")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultMaxDelay caps the echo delay when MAX_DELAY is not set.
+const defaultMaxDelay = 30 * time.Second
+
+// delayConfig holds the settings that shape the echo response delay.
+type delayConfig struct {
+	base       time.Duration
+	perSegment time.Duration
+	max        time.Duration
+}
+
+// loadDelayConfig reads the echo delay settings from DELAY,
+// DELAY_PER_SEGMENT and MAX_DELAY. A delay set through /admin/config takes
+// the place of DELAY.
+func loadDelayConfig() (delayConfig, error) {
+	var cfg delayConfig
+	var err error
+
+	if cfg.base, err = envDuration("DELAY"); err != nil {
+		return cfg, err
+	}
+	if delay := currentOverrides().delay; delay != nil {
+		cfg.base = *delay
+	}
+	if cfg.perSegment, err = envDuration("DELAY_PER_SEGMENT"); err != nil {
+		return cfg, err
+	}
+	if cfg.max, err = envDuration("MAX_DELAY"); err != nil {
+		return cfg, err
+	}
+	if os.Getenv("MAX_DELAY") == "" {
+		cfg.max = defaultMaxDelay
+	}
+	return cfg, nil
+}
+
+// delayFor returns the delay for a request to urlPath: the base delay plus
+// the per-segment delay for every path segment, capped at the maximum. For
+// example, "/a/b/c" has three segments.
+func (cfg delayConfig) delayFor(urlPath string) time.Duration {
+	segments := strings.Count(strings.TrimSuffix(urlPath, "/"), "/")
+
+	delay := cfg.base + time.Duration(segments)*cfg.perSegment
+	return min(delay, cfg.max)
+}
+
+// delayRequest waits for the configured echo delay before the request is
+// answered. It reports whether the request should still be answered, which
+// is not the case when the client went away while waiting.
+func delayRequest(req *http.Request) bool {
+	cfg, _ := loadDelayConfig()
+
+	delay := cfg.delayFor(req.URL.Path)
+	if delay <= 0 {
+		return true
+	}
+
+	fmt.Printf("%s | delay | %s\n", req.RemoteAddr, delay)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-req.Context().Done():
+		return false
+	}
+}
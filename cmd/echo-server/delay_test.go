@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestDelayPerSegment verifies the delay scales with the path depth and is capped
+func TestDelayPerSegment(t *testing.T) {
+	cfg := delayConfig{
+		base:       10 * time.Millisecond,
+		perSegment: 50 * time.Millisecond,
+		max:        200 * time.Millisecond,
+	}
+
+	tests := []struct {
+		path string
+		want time.Duration
+	}{
+		{"/", 10 * time.Millisecond},
+		{"/a", 60 * time.Millisecond},
+		{"/a/b/c", 160 * time.Millisecond},
+		{"/a/b/c/", 160 * time.Millisecond},
+		{"/a/b/c/d/e", 200 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.delayFor(tt.path); got != tt.want {
+			t.Errorf("delayFor(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+
+	t.Run("Echo request", func(t *testing.T) {
+		t.Setenv("DELAY_PER_SEGMENT", "50ms")
+
+		start := time.Now()
+		resp, err := http.Get(httpBaseURL + "/a/b/c")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+			t.Errorf("expected a delay of at least 150ms, took %s", elapsed)
+		}
+	})
+
+	t.Run("Max delay", func(t *testing.T) {
+		t.Setenv("DELAY", "10s")
+		t.Setenv("MAX_DELAY", "100ms")
+
+		start := time.Now()
+		resp, err := http.Get(httpBaseURL + "/capped")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("expected the delay to be capped at 100ms, took %s", elapsed)
+		}
+	})
+
+	t.Log("TestDelayPerSegment passed")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envBool reports whether the environment variable name is set to a true
+// value such as "true" or "1".
+func envBool(name string) bool {
+	b, _ := strconv.ParseBool(os.Getenv(name))
+	return b
+}
+
+// envInt parses the environment variable name as a non-negative integer. It
+// returns zero when the variable is unset.
+func envInt(name string) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s: %q is not a valid non-negative integer", name, v)
+	}
+	return n, nil
+}
+
+// envDuration parses the environment variable name as a non-negative
+// duration (e.g. "500ms", "2s"). It returns zero when the variable is unset.
+func envDuration(name string) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s: %q is not a valid duration", name, v)
+	}
+	return d, nil
+}
+
+// envFraction parses the environment variable name as a number between 0
+// and 1, such as an error rate. It returns zero when the variable is unset.
+func envFraction(name string) (float64, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 || f > 1 {
+		return 0, fmt.Errorf("%s: %q must be a number between 0 and 1", name, v)
+	}
+	return f, nil
+}
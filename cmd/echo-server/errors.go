@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Error response formats selected by ERROR_FORMAT.
+const (
+	// errorFormatDefault keeps each endpoint's own shape: {"error": ...} for
+	// the echo server endpoints and {"code": ..., "message": ...} for the
+	// pet store.
+	errorFormatDefault = "default"
+
+	// errorFormatError is {"error": message}.
+	errorFormatError = "error"
+
+	// errorFormatCode is {"code": status, "message": message}.
+	errorFormatCode = "code"
+
+	// errorFormatProblem is an RFC 7807 Problem Details object served as
+	// application/problem+json.
+	errorFormatProblem = "problem"
+)
+
+// errorFormat returns the error response format configured by ERROR_FORMAT.
+// It defaults to errorFormatDefault.
+func errorFormat() (string, error) {
+	v := strings.ToLower(os.Getenv("ERROR_FORMAT"))
+	switch v {
+	case "", errorFormatDefault:
+		return errorFormatDefault, nil
+	case errorFormatError, errorFormatCode, errorFormatProblem:
+		return v, nil
+	}
+	return "", fmt.Errorf("ERROR_FORMAT: %q must be one of default, error, code or problem", v)
+}
+
+// problemDetails is an RFC 7807 Problem Details object.
+type problemDetails struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// writeError writes a JSON error response in the format configured by
+// ERROR_FORMAT, using {"error": message} by default.
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeErrorAs(w, code, message, errorFormatError)
+}
+
+// writeErrorAs is like writeError, but uses fallback as the format when
+// ERROR_FORMAT is unset or "default".
+func writeErrorAs(w http.ResponseWriter, code int, message, fallback string) {
+	format, _ := errorFormat() // Validated at startup by validateConfig
+	if format == errorFormatDefault {
+		format = fallback
+	}
+
+	switch format {
+	case errorFormatProblem:
+		w.Header().Set("Content-Type", "application/problem+json")
+		writeJSONBody(w, code, problemDetails{
+			Type:   "about:blank",
+			Title:  http.StatusText(code),
+			Status: code,
+			Detail: message,
+		})
+	case errorFormatCode:
+		writeJSON(w, code, map[string]interface{}{"code": code, "message": message})
+	default:
+		writeJSON(w, code, map[string]string{"error": message})
+	}
+}
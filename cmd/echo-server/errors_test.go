@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestErrorFormat verifies that ERROR_FORMAT switches the shape of JSON error responses
+func TestErrorFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		path     string
+		wantType string
+		wantKeys []string
+	}{
+		{"Default echo error", "", "/throw?code=503", "application/json", []string{"error"}},
+		{"Default pet store error", "", "/v1/pets/999", "application/json", []string{"code", "message"}},
+		{"Error echo error", "error", "/throw?code=503", "application/json", []string{"error"}},
+		{"Error pet store error", "error", "/v1/pets/999", "application/json", []string{"error"}},
+		{"Code echo error", "code", "/throw?code=503", "application/json", []string{"code", "message"}},
+		{"Code pet store error", "code", "/v1/pets/999", "application/json", []string{"code", "message"}},
+		{"Problem echo error", "problem", "/throw?code=503", "application/problem+json", []string{"type", "title", "status", "detail"}},
+		{"Problem pet store error", "problem", "/v1/pets/999", "application/problem+json", []string{"type", "title", "status", "detail"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ERROR_FORMAT", tt.format)
+
+			resp, err := http.Get(httpBaseURL + tt.path)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode < 400 {
+				t.Fatalf("expected an error status, got %d", resp.StatusCode)
+			}
+
+			if ct := resp.Header.Get("Content-Type"); ct != tt.wantType {
+				t.Errorf("expected Content-Type %s, got %s", tt.wantType, ct)
+			}
+
+			var result map[string]interface{}
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			assertJSONKeys(t, result, tt.wantKeys...)
+
+			if tt.format == "problem" {
+				if status, _ := result["status"].(float64); int(status) != resp.StatusCode {
+					t.Errorf("expected status %d, got %v", resp.StatusCode, result["status"])
+				}
+				if result["title"] != http.StatusText(resp.StatusCode) {
+					t.Errorf("expected title %q, got %v", http.StatusText(resp.StatusCode), result["title"])
+				}
+			}
+		})
+	}
+
+	t.Log("TestErrorFormat passed")
+}
+
+// TestErrorFormatValidation verifies that unknown error formats are rejected
+func TestErrorFormatValidation(t *testing.T) {
+	for _, v := range []string{"xml", "rfc7807"} {
+		t.Setenv("ERROR_FORMAT", v)
+		if _, err := errorFormat(); err == nil || !strings.Contains(err.Error(), "ERROR_FORMAT") {
+			t.Errorf("expected error for ERROR_FORMAT=%q, got %v", v, err)
+		}
+	}
+
+	t.Log("TestErrorFormatValidation passed")
+}
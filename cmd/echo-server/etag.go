@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// conditionalHeaders are left out of the echo ETag, so that revalidating a
+// cached echo does not change its ETag.
+var conditionalHeaders = map[string]bool{
+	"If-None-Match":     true,
+	"If-Modified-Since": true,
+	"Cache-Control":     true,
+	"Pragma":            true,
+}
+
+// echoETag returns a weak ETag identifying the echo of req, computed from the
+// request line, host, headers and body only. Identical requests therefore
+// get the same ETag even though parts of the echo, such as the hostname or
+// timestamps, may vary. The body is buffered and restored.
+func echoETag(req *http.Request) string {
+	body, _ := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n%s\n", req.Method, req.URL.RequestURI(), req.Host)
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		if !conditionalHeaders[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s: %s\n", name, strings.Join(req.Header[name], ", "))
+	}
+
+	h.Write(body)
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches reports whether the If-None-Match header value matches etag,
+// using the weak comparison required for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestEchoETag verifies identical echo requests share an ETag and revalidate with 304
+func TestEchoETag(t *testing.T) {
+	t.Setenv("ECHO_ETAG", "true")
+
+	get := func(t *testing.T, path, ifNoneMatch string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest("GET", httpBaseURL+path, nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	first := get(t, "/cached?v=1", "")
+	etag := first.Header.Get("ETag")
+	if first.StatusCode != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d and %q", first.StatusCode, etag)
+	}
+
+	if again := get(t, "/cached?v=1", "").Header.Get("ETag"); again != etag {
+		t.Errorf("expected identical requests to share the ETag %s, got %s", etag, again)
+	}
+
+	t.Run("Matching ETag", func(t *testing.T) {
+		resp := get(t, "/cached?v=1", etag)
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("expected status 304, got %d", resp.StatusCode)
+		}
+		if resp.Header.Get("ETag") != etag {
+			t.Errorf("expected the 304 to carry ETag %s, got %s", etag, resp.Header.Get("ETag"))
+		}
+	})
+
+	t.Run("Different request", func(t *testing.T) {
+		resp := get(t, "/cached?v=2", etag)
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+		if resp.Header.Get("ETag") == etag {
+			t.Error("expected a different request to get a different ETag")
+		}
+	})
+
+	t.Log("TestEchoETag passed")
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// writeFooter writes a summary line for an echo response: the size of the
+// body written so far, excluding the footer itself, the time spent since the
+// handler started and the hostname of the server.
+func writeFooter(w io.Writer, size int64, start time.Time) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	fmt.Fprintf(
+		w,
+		"\n--\nSize: %d bytes | Time: %s | Served by: %s\n",
+		size,
+		time.Since(start).Round(time.Microsecond),
+		host,
+	)
+}
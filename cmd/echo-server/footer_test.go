@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestResponseFooter verifies the footer reports the body size, timing and hostname
+func TestResponseFooter(t *testing.T) {
+	t.Setenv("SEND_FOOTER", "true")
+
+	resp, err := http.Get(httpBaseURL + "/footer")
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read response body: %v", err)
+	}
+
+	echoed, footer, ok := strings.Cut(string(body), "\n--\n")
+	if !ok {
+		t.Fatalf("expected a footer, got: %s", body)
+	}
+
+	match := regexp.MustCompile(`^Size: (\d+) bytes \| Time: \S+ \| Served by: (.+)\n$`).FindStringSubmatch(footer)
+	if match == nil {
+		t.Fatalf("unexpected footer %q", footer)
+	}
+
+	// The size covers the body before the footer only
+	if size, _ := strconv.Atoi(match[1]); size != len(echoed) {
+		t.Errorf("expected size %d, got %d", len(echoed), size)
+	}
+
+	if host, _ := os.Hostname(); match[2] != host {
+		t.Errorf("expected served by %q, got %q", host, match[2])
+	}
+
+	t.Log("TestResponseFooter passed")
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// trustedProxies returns the proxies whose forwarded headers are trusted, as
+// configured by TRUST_PROXY: "true" (the default) trusts any peer, "false"
+// none, and otherwise it is a comma-separated list of IP addresses and CIDR
+// ranges. The second result reports whether any peer is trusted.
+func trustedProxies() ([]netip.Prefix, bool, error) {
+	v := strings.TrimSpace(os.Getenv("TRUST_PROXY"))
+	switch strings.ToLower(v) {
+	case "", "true":
+		return nil, true, nil
+	case "false":
+		return nil, false, nil
+	}
+
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(v, ",") {
+		entry = strings.TrimSpace(entry)
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, false, fmt.Errorf("TRUST_PROXY: %q is not an IP address or CIDR range", entry)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, false, nil
+}
+
+// isTrustedProxy reports whether the peer that sent req is trusted to set
+// forwarded headers.
+func isTrustedProxy(req *http.Request) bool {
+	prefixes, trustAll, _ := trustedProxies() // Validated at startup by validateConfig
+	if trustAll {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstHeaderValue returns the first comma-separated value of the header
+// name, as set by the proxy closest to the client.
+func firstHeaderValue(h http.Header, name string) string {
+	first, _, _ := strings.Cut(h.Get(name), ",")
+	return strings.TrimSpace(first)
+}
+
+// forwardedURL reconstructs the URL the client used from the
+// X-Forwarded-Proto, X-Forwarded-Host and X-Forwarded-Port headers, falling
+// back to the request's own scheme and host for missing headers. Default
+// ports are left out.
+func forwardedURL(req *http.Request) string {
+	u, _ := url.Parse(requestURL(req))
+
+	if proto := firstHeaderValue(req.Header, "X-Forwarded-Proto"); proto != "" {
+		u.Scheme = strings.ToLower(proto)
+	}
+	if host := firstHeaderValue(req.Header, "X-Forwarded-Host"); host != "" {
+		u.Host = host
+	}
+	if port := firstHeaderValue(req.Header, "X-Forwarded-Port"); port != "" {
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = u.Hostname()
+		if strings.Contains(u.Host, ":") {
+			u.Host = "[" + u.Host + "]"
+		}
+	}
+	return u.String()
+}
+
+// writeForwardedURL writes the URL the request was received on and the
+// external URL reconstructed from the forwarded headers, to debug the URLs
+// an application behind a proxy generates.
+func writeForwardedURL(w io.Writer, req *http.Request) {
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Forwarded URL")
+	fmt.Fprintf(w, "  Received: %s\n", requestURL(req))
+
+	if !isTrustedProxy(req) {
+		fmt.Fprintf(w, "  Effective: %s (forwarded headers ignored: %s is not a trusted proxy)\n", requestURL(req), req.RemoteAddr)
+		return
+	}
+	fmt.Fprintf(w, "  Effective: %s\n", forwardedURL(req))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestForwardedURL verifies SEND_FORWARDED_URL reconstructs the external URL from trusted forwarded headers
+func TestForwardedURL(t *testing.T) {
+	t.Setenv("SEND_FORWARDED_URL", "true")
+
+	get := func(t *testing.T, headers map[string]string) string {
+		t.Helper()
+
+		req, err := http.NewRequest("GET", httpBaseURL+"/app/login?next=%2Fhome", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+		return string(body)
+	}
+
+	forwarded := map[string]string{
+		"X-Forwarded-Proto": "https",
+		"X-Forwarded-Host":  "example.com, internal.lb",
+		"X-Forwarded-Port":  "8443",
+	}
+
+	tests := []struct {
+		name       string
+		trustProxy string
+		headers    map[string]string
+		wantEffect string
+	}{
+		{"All headers", "", forwarded, "Effective: https://example.com:8443/app/login?next=%2Fhome\n"},
+		{"Default port", "", map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "example.com", "X-Forwarded-Port": "443"}, "Effective: https://example.com/app/login?next=%2Fhome\n"},
+		{"No headers", "", nil, "Effective: http://localhost:" + testHTTPPort + "/app/login?next=%2Fhome\n"},
+		{"Trusted range", "127.0.0.0/8, ::1", forwarded, "Effective: https://example.com:8443/app/login?next=%2Fhome\n"},
+		{"Untrusted peer", "10.0.0.1", forwarded, "(forwarded headers ignored:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRUST_PROXY", tt.trustProxy)
+
+			body := get(t, tt.headers)
+			if !strings.Contains(body, "Received: http://localhost:"+testHTTPPort+"/app/login?next=%2Fhome\n") {
+				t.Errorf("expected the received URL, got: %s", body)
+			}
+			if !strings.Contains(body, tt.wantEffect) {
+				t.Errorf("expected %q, got: %s", tt.wantEffect, body)
+			}
+		})
+	}
+
+	t.Run("Invalid TRUST_PROXY", func(t *testing.T) {
+		t.Setenv("TRUST_PROXY", "proxy.internal")
+		if _, _, err := trustedProxies(); err == nil {
+			t.Error("expected error for a host name")
+		}
+	})
+
+	t.Log("TestForwardedURL passed")
+}
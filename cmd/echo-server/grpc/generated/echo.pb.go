@@ -109,6 +109,70 @@ func (x *EchoResponse) GetMessage() string {
 	return ""
 }
 
+type EchoStreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// message is sent in every streamed response, with the {{.Index}}
+	// (counting from 0) and {{.Count}} placeholders replaced.
+	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// count is the number of responses to stream.
+	Count uint32 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	// interval_ms is the pause between responses, in milliseconds.
+	IntervalMs    uint32 `protobuf:"varint,3,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EchoStreamRequest) Reset() {
+	*x = EchoStreamRequest{}
+	mi := &file_grpc_echo_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EchoStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EchoStreamRequest) ProtoMessage() {}
+
+func (x *EchoStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpc_echo_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EchoStreamRequest.ProtoReflect.Descriptor instead.
+func (*EchoStreamRequest) Descriptor() ([]byte, []int) {
+	return file_grpc_echo_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *EchoStreamRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *EchoStreamRequest) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *EchoStreamRequest) GetIntervalMs() uint32 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
+}
+
 var File_grpc_echo_proto protoreflect.FileDescriptor
 
 const file_grpc_echo_proto_rawDesc = "" +
@@ -117,9 +181,16 @@ const file_grpc_echo_proto_rawDesc = "" +
 	"\vEchoRequest\x12\x18\n" +
 	"\amessage\x18\x01 \x01(\tR\amessage\"(\n" +
 	"\fEchoResponse\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage27\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"d\n" +
+	"\x11EchoStreamRequest\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\rR\x05count\x12\x1f\n" +
+	"\vinterval_ms\x18\x03 \x01(\rR\n" +
+	"intervalMs2v\n" +
 	"\x04Echo\x12/\n" +
-	"\x04Echo\x12\x11.echo.EchoRequest\x1a\x12.echo.EchoResponse\"\x00B\bZ\x06.;echob\x06proto3"
+	"\x04Echo\x12\x11.echo.EchoRequest\x1a\x12.echo.EchoResponse\"\x00\x12=\n" +
+	"\n" +
+	"EchoStream\x12\x17.echo.EchoStreamRequest\x1a\x12.echo.EchoResponse\"\x000\x01B\bZ\x06.;echob\x06proto3"
 
 var (
 	file_grpc_echo_proto_rawDescOnce sync.Once
@@ -133,16 +204,19 @@ func file_grpc_echo_proto_rawDescGZIP() []byte {
 	return file_grpc_echo_proto_rawDescData
 }
 
-var file_grpc_echo_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_grpc_echo_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_grpc_echo_proto_goTypes = []any{
-	(*EchoRequest)(nil),  // 0: echo.EchoRequest
-	(*EchoResponse)(nil), // 1: echo.EchoResponse
+	(*EchoRequest)(nil),       // 0: echo.EchoRequest
+	(*EchoResponse)(nil),      // 1: echo.EchoResponse
+	(*EchoStreamRequest)(nil), // 2: echo.EchoStreamRequest
 }
 var file_grpc_echo_proto_depIdxs = []int32{
 	0, // 0: echo.Echo.Echo:input_type -> echo.EchoRequest
-	1, // 1: echo.Echo.Echo:output_type -> echo.EchoResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
+	2, // 1: echo.Echo.EchoStream:input_type -> echo.EchoStreamRequest
+	1, // 2: echo.Echo.Echo:output_type -> echo.EchoResponse
+	1, // 3: echo.Echo.EchoStream:output_type -> echo.EchoResponse
+	2, // [2:4] is the sub-list for method output_type
+	0, // [0:2] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -159,7 +233,7 @@ func file_grpc_echo_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_grpc_echo_proto_rawDesc), len(file_grpc_echo_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
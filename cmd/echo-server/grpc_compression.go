@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+
+	// Registers the gzip compressor, so the server accepts gzip-compressed
+	// requests, advertises gzip in grpc-accept-encoding, and compresses each
+	// response the same way as its request.
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+// grpcCompression returns the compressor gRPC responses are forced to use,
+// as configured by GRPC_COMPRESSION: "gzip" to compress every response the
+// client accepts gzip for, or "identity" to never compress them. It returns
+// "" when unset, compressing responses as their request was.
+func grpcCompression() (string, error) {
+	name := os.Getenv("GRPC_COMPRESSION")
+	if name == "" || name == encoding.Identity {
+		return name, nil
+	}
+	if encoding.GetCompressor(name) == nil {
+		return "", fmt.Errorf("GRPC_COMPRESSION: unsupported compressor %q, must be gzip or identity", name)
+	}
+	return name, nil
+}
+
+// setSendCompressor makes the response of the call with ctx use the
+// compressor configured by GRPC_COMPRESSION. Clients that do not accept it
+// get responses compressed as their request was.
+func setSendCompressor(ctx context.Context, method string) {
+	name, _ := grpcCompression() // Validated at startup by validateConfig
+	if name == "" {
+		return
+	}
+	if err := grpc.SetSendCompressor(ctx, name); err != nil {
+		fmt.Printf("gRPC %s: not forcing %s compression: %v\n", method, name, err)
+	}
+}
+
+// compressionUnaryInterceptor applies GRPC_COMPRESSION to unary calls.
+func compressionUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	setSendCompressor(ctx, info.FullMethod)
+	return handler(ctx, req)
+}
+
+// compressionStreamInterceptor applies GRPC_COMPRESSION to streaming calls.
+func compressionStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	setSendCompressor(ss.Context(), info.FullMethod)
+	return handler(srv, ss)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	echo "http-echo/cmd/echo-server/grpc/generated"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/stats"
+)
+
+// responseSizes records the uncompressed and compressed sizes of the
+// messages a gRPC client receives.
+type responseSizes struct {
+	mu       sync.Mutex
+	payloads []stats.InPayload
+}
+
+func (s *responseSizes) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (s *responseSizes) HandleRPC(_ context.Context, rs stats.RPCStats) {
+	if in, ok := rs.(*stats.InPayload); ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.payloads = append(s.payloads, *in)
+	}
+}
+
+func (s *responseSizes) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (s *responseSizes) HandleConn(context.Context, stats.ConnStats) {}
+
+// last returns the sizes of the last message received.
+func (s *responseSizes) last(t *testing.T) stats.InPayload {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.payloads) == 0 {
+		t.Fatal("expected a response message")
+	}
+	return s.payloads[len(s.payloads)-1]
+}
+
+// TestGRPCCompression verifies gzip-compressed gRPC calls are echoed with the same compression, and that GRPC_COMPRESSION forces it
+func TestGRPCCompression(t *testing.T) {
+	sizes := &responseSizes{}
+	conn, err := grpc.Dial(
+		grpcAddress,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(sizes),
+	)
+	if err != nil {
+		t.Fatalf("failed to create gRPC client: %v", err)
+	}
+	defer conn.Close()
+
+	client := echo.NewEchoClient(conn)
+	message := strings.Repeat("compress me ", 100)
+
+	tests := []struct {
+		name        string
+		compression string
+		opts        []grpc.CallOption
+		compressed  bool
+	}{
+		{"Gzip request", "", []grpc.CallOption{grpc.UseCompressor(gzip.Name)}, true},
+		{"Uncompressed request", "", nil, false},
+		{"Forced gzip", "gzip", nil, true},
+		{"Forced identity", "identity", []grpc.CallOption{grpc.UseCompressor(gzip.Name)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GRPC_COMPRESSION", tt.compression)
+
+			resp, err := client.Echo(context.Background(), &echo.EchoRequest{Message: message}, tt.opts...)
+			if err != nil {
+				t.Fatalf("Echo failed: %v", err)
+			}
+			if resp.GetMessage() != message {
+				t.Errorf("expected the message to be echoed, got %q", resp.GetMessage())
+			}
+
+			in := sizes.last(t)
+			if compressed := in.CompressedLength < in.Length; compressed != tt.compressed {
+				t.Errorf("expected compressed response: %t, got %d byte(s) for %d uncompressed", tt.compressed, in.CompressedLength, in.Length)
+			}
+		})
+	}
+
+	t.Run("Invalid compressor", func(t *testing.T) {
+		t.Setenv("GRPC_COMPRESSION", "brotli")
+		if _, err := grpcCompression(); err == nil {
+			t.Error("expected an error for an unregistered compressor")
+		}
+	})
+
+	t.Log("TestGRPCCompression passed")
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcCPULoadHeader is the request metadata key setting the CPU time a call
+// burns before it is handled, overriding GRPC_CPU_LOAD.
+const grpcCPULoadHeader = "x-echo-cpu-load"
+
+// maxCPULoad caps the CPU time burnt per gRPC call.
+const maxCPULoad = 10 * time.Second
+
+// grpcCPULoad returns the default CPU time burnt per gRPC call, as configured
+// by GRPC_CPU_LOAD. It defaults to zero.
+func grpcCPULoad() (time.Duration, error) {
+	load, err := envDuration("GRPC_CPU_LOAD")
+	if err != nil {
+		return 0, err
+	}
+	if load > maxCPULoad {
+		return 0, fmt.Errorf("GRPC_CPU_LOAD: %s exceeds the maximum of %s", load, maxCPULoad)
+	}
+	return load, nil
+}
+
+// callCPULoad returns the CPU time to burn for the call, from its
+// x-echo-cpu-load metadata or else GRPC_CPU_LOAD.
+func callCPULoad(ctx context.Context) (time.Duration, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(grpcCPULoadHeader)
+	if len(values) == 0 {
+		load, _ := grpcCPULoad() // Validated at startup by validateConfig
+		return load, nil
+	}
+
+	load, err := time.ParseDuration(values[0])
+	if err != nil || load < 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "%s: %q is not a valid non-negative duration", grpcCPULoadHeader, values[0])
+	}
+	if load > maxCPULoad {
+		return 0, status.Errorf(codes.InvalidArgument, "%s: %s exceeds the maximum of %s", grpcCPULoadHeader, load, maxCPULoad)
+	}
+	return load, nil
+}
+
+// burnCPU keeps a CPU busy for d, unlike a sleep, to simulate CPU-bound
+// processing. It stops early with the context's status when ctx is done.
+func burnCPU(ctx context.Context, d time.Duration) error {
+	deadline := time.Now().Add(d)
+	var sum uint32
+	buf := make([]byte, 4096)
+
+	for time.Now().Before(deadline) {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		// Roughly tens of microseconds of work between checks
+		for i := 0; i < 16; i++ {
+			sum = crc32.Update(sum, crc32.IEEETable, buf)
+		}
+		buf[0] = byte(sum)
+	}
+	return nil
+}
+
+// cpuLoadUnaryInterceptor burns CPU time before unary calls, to test clients
+// against a saturated server.
+func cpuLoadUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	load, err := callCPULoad(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if load > 0 {
+		fmt.Printf("gRPC %s: burning %s of CPU\n", info.FullMethod, load)
+		if err := burnCPU(ctx, load); err != nil {
+			return nil, err
+		}
+	}
+	return handler(ctx, req)
+}
+
+// cpuLoadStreamInterceptor burns CPU time before streaming calls.
+func cpuLoadStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	load, err := callCPULoad(ss.Context())
+	if err != nil {
+		return err
+	}
+	if load > 0 {
+		fmt.Printf("gRPC %s: burning %s of CPU\n", info.FullMethod, load)
+		if err := burnCPU(ss.Context(), load); err != nil {
+			return err
+		}
+	}
+	return handler(srv, ss)
+}
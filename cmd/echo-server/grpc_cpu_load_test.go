@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	echo "http-echo/cmd/echo-server/grpc/generated"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestGRPCCPULoad verifies gRPC calls burn the configured CPU time and stop at the deadline
+func TestGRPCCPULoad(t *testing.T) {
+	conn, err := grpc.Dial(
+		grpcAddress,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to create gRPC client: %v", err)
+	}
+	defer conn.Close()
+
+	client := echo.NewEchoClient(conn)
+
+	call := func(ctx context.Context, load string) (time.Duration, error) {
+		if load != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, grpcCPULoadHeader, load)
+		}
+		start := time.Now()
+		_, err := client.Echo(ctx, &echo.EchoRequest{Message: "load"})
+		return time.Since(start), err
+	}
+
+	t.Run("From metadata", func(t *testing.T) {
+		elapsed, err := call(context.Background(), "200ms")
+		if err != nil {
+			t.Fatalf("failed to call Echo: %v", err)
+		}
+		if elapsed < 200*time.Millisecond {
+			t.Errorf("expected the call to take at least 200ms, took %s", elapsed)
+		}
+	})
+
+	t.Run("From environment", func(t *testing.T) {
+		t.Setenv("GRPC_CPU_LOAD", "150ms")
+
+		elapsed, err := call(context.Background(), "")
+		if err != nil {
+			t.Fatalf("failed to call Echo: %v", err)
+		}
+		if elapsed < 150*time.Millisecond {
+			t.Errorf("expected the call to take at least 150ms, took %s", elapsed)
+		}
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		elapsed, err := call(ctx, "5s")
+		if status.Code(err) != codes.DeadlineExceeded {
+			t.Errorf("expected DeadlineExceeded, got %v", err)
+		}
+		if elapsed > time.Second {
+			t.Errorf("expected the call to end at the deadline, took %s", elapsed)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := call(context.Background(), "1h"); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected InvalidArgument for a load over the cap, got %v", err)
+		}
+	})
+
+	t.Log("TestGRPCCPULoad passed")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	echo "http-echo/cmd/echo-server/grpc/generated"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcEchoHandler handles POST /grpc-echo, a JSON shim over the gRPC Echo
+// call for clients without a gRPC stack. The {"message": "..."} body is passed
+// to srv.Echo and its response is returned as JSON; gRPC errors are mapped to
+// the equivalent HTTP status.
+func grpcEchoHandler(srv *grpcEchoServer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		resp, err := srv.Echo(r.Context(), &echo.EchoRequest{Message: req.Message})
+		if err != nil {
+			st := status.Convert(err)
+			writeError(w, grpcCodeToHTTP(st.Code()), st.Message())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]string{"message": resp.GetMessage()})
+	}
+}
+
+// grpcCodeToHTTP returns the HTTP status code corresponding to a gRPC status
+// code, following the gRPC-HTTP mapping used by gateways.
+func grpcCodeToHTTP(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // Client Closed Request
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
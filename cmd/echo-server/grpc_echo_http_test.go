@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGRPCEchoHTTP verifies /grpc-echo echoes the JSON message through the gRPC echo logic
+func TestGRPCEchoHTTP(t *testing.T) {
+	resp, err := http.Post(httpBaseURL+"/grpc-echo", "application/json", strings.NewReader(`{"message":"hello over HTTP"}`))
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if result["message"] != "hello over HTTP" {
+		t.Errorf("expected message %q, got %q", "hello over HTTP", result["message"])
+	}
+
+	t.Log("TestGRPCEchoHTTP passed")
+}
+
+// TestGRPCEchoHTTPErrors verifies invalid bodies and gRPC errors are mapped to HTTP statuses
+func TestGRPCEchoHTTPErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		readyAt    time.Time
+		body       string
+		wantStatus int
+	}{
+		{"Invalid body", time.Now(), `not json`, http.StatusBadRequest},
+		{"Warming up", time.Now().Add(time.Minute), `{"message":"hi"}`, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := grpcEchoHandler(&grpcEchoServer{readyAt: tt.readyAt})
+
+			rw := httptest.NewRecorder()
+			handler(rw, httptest.NewRequest("POST", "/grpc-echo", strings.NewReader(tt.body)))
+
+			if rw.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rw.Code)
+			}
+		})
+	}
+
+	t.Log("TestGRPCEchoHTTPErrors passed")
+}
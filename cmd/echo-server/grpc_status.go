@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// grpcStatusHeader is the request metadata key making a call fail with the
+// given status code, as a name such as "NOT_FOUND" or a number such as "5".
+const grpcStatusHeader = "x-echo-status"
+
+// grpcStatusDetailsHeader is the request metadata key selecting the
+// comma-separated details attached to an injected status, from the keys of
+// grpcStatusDetails.
+const grpcStatusDetailsHeader = "x-echo-status-details"
+
+// grpcStatusDetails builds the error details that can be attached to an
+// injected status, keyed by the name selecting them in metadata. Each gets the
+// full method name of the call.
+var grpcStatusDetails = map[string]func(method string) protoadapt.MessageV1{
+	"error-info": func(method string) protoadapt.MessageV1 {
+		return &errdetails.ErrorInfo{
+			Reason:   "ECHO_INJECTED_ERROR",
+			Domain:   "echo-server",
+			Metadata: map[string]string{"method": method},
+		}
+	},
+	"bad-request": func(method string) protoadapt.MessageV1 {
+		return &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "message", Description: "injected violation for " + method},
+			},
+		}
+	},
+	"retry-info": func(method string) protoadapt.MessageV1 {
+		return &errdetails.RetryInfo{RetryDelay: durationpb.New(time.Second)}
+	},
+	"debug-info": func(method string) protoadapt.MessageV1 {
+		return &errdetails.DebugInfo{Detail: "injected error for " + method}
+	},
+}
+
+// injectedStatus returns the status a call should fail with according to its
+// x-echo-status and x-echo-status-details metadata, or nil when none is
+// requested. Invalid metadata fails the call with InvalidArgument.
+func injectedStatus(ctx context.Context, method string) (*status.Status, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(grpcStatusHeader)
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	var code codes.Code
+	v := strings.TrimSpace(values[0])
+	if _, err := strconv.Atoi(v); err != nil {
+		v = strconv.Quote(strings.ToUpper(v))
+	}
+	if err := code.UnmarshalJSON([]byte(v)); err != nil || code == codes.OK {
+		return nil, status.Errorf(codes.InvalidArgument, "%s: %q is not a valid non-OK status code", grpcStatusHeader, values[0])
+	}
+
+	st := status.Newf(code, "injected %s error", code)
+	for _, value := range md.Get(grpcStatusDetailsHeader) {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			detail, ok := grpcStatusDetails[name]
+			if !ok {
+				return nil, status.Errorf(codes.InvalidArgument, "%s: unknown detail %q (want error-info, bad-request, retry-info or debug-info)", grpcStatusDetailsHeader, name)
+			}
+
+			withDetail, err := st.WithDetails(detail(method))
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to attach %s detail: %v", name, err)
+			}
+			st = withDetail
+		}
+	}
+	return st, nil
+}
+
+// statusUnaryInterceptor fails unary calls with the status requested in their
+// metadata, to test clients parsing gRPC errors and their details.
+func statusUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	st, err := injectedStatus(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	if st != nil {
+		fmt.Printf("gRPC %s: injecting %s error with %d details\n", info.FullMethod, st.Code(), len(st.Details()))
+		return nil, st.Err()
+	}
+	return handler(ctx, req)
+}
+
+// statusStreamInterceptor fails streaming calls with the status requested in
+// their metadata.
+func statusStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	st, err := injectedStatus(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	if st != nil {
+		fmt.Printf("gRPC %s: injecting %s error with %d details\n", info.FullMethod, st.Code(), len(st.Details()))
+		return st.Err()
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	echo "http-echo/cmd/echo-server/grpc/generated"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestGRPCStatusDetails verifies injected gRPC errors carry the status details selected in metadata
+func TestGRPCStatusDetails(t *testing.T) {
+	conn, err := grpc.Dial(
+		grpcAddress,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to create gRPC client: %v", err)
+	}
+	defer conn.Close()
+
+	client := echo.NewEchoClient(conn)
+
+	call := func(kv ...string) error {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), kv...)
+		_, err := client.Echo(ctx, &echo.EchoRequest{Message: "status"})
+		return err
+	}
+
+	t.Run("With details", func(t *testing.T) {
+		err := call(grpcStatusHeader, "FAILED_PRECONDITION", grpcStatusDetailsHeader, "error-info,bad-request")
+		st, ok := status.FromError(err)
+		if !ok || st.Code() != codes.FailedPrecondition {
+			t.Fatalf("expected FailedPrecondition, got %v", err)
+		}
+
+		details := st.Details()
+		if len(details) != 2 {
+			t.Fatalf("expected 2 details, got %d: %v", len(details), details)
+		}
+
+		info, ok := details[0].(*errdetails.ErrorInfo)
+		if !ok {
+			t.Fatalf("expected ErrorInfo, got %T", details[0])
+		}
+		if info.GetDomain() != "echo-server" || info.GetMetadata()["method"] != "/echo.Echo/Echo" {
+			t.Errorf("unexpected ErrorInfo: %v", info)
+		}
+
+		badRequest, ok := details[1].(*errdetails.BadRequest)
+		if !ok {
+			t.Fatalf("expected BadRequest, got %T", details[1])
+		}
+		if v := badRequest.GetFieldViolations(); len(v) != 1 || v[0].GetField() != "message" {
+			t.Errorf("unexpected BadRequest: %v", badRequest)
+		}
+	})
+
+	t.Run("Numeric code without details", func(t *testing.T) {
+		st, _ := status.FromError(call(grpcStatusHeader, "5"))
+		if st.Code() != codes.NotFound {
+			t.Errorf("expected NotFound, got %v", st.Code())
+		}
+		if len(st.Details()) != 0 {
+			t.Errorf("expected no details, got %v", st.Details())
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, kv := range [][]string{
+			{grpcStatusHeader, "OK"},
+			{grpcStatusHeader, "NOPE"},
+			{grpcStatusHeader, "INTERNAL", grpcStatusDetailsHeader, "stack-trace"},
+		} {
+			if st, _ := status.FromError(call(kv...)); st.Code() != codes.InvalidArgument {
+				t.Errorf("expected InvalidArgument for %v, got %v", kv, st.Code())
+			}
+		}
+	})
+
+	t.Run("Not requested", func(t *testing.T) {
+		if err := call(); err != nil {
+			t.Errorf("expected the call to succeed, got %v", err)
+		}
+	})
+
+	t.Log("TestGRPCStatusDetails passed")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	echo "http-echo/cmd/echo-server/grpc/generated"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxStreamMessages caps the number of responses sent by EchoStream.
+const maxStreamMessages = 10000
+
+// maxStreamInterval caps the pause between EchoStream responses.
+const maxStreamInterval = time.Minute
+
+// Placeholders replaced in each EchoStream message.
+const (
+	streamIndexPlaceholder = "{{.Index}}"
+	streamCountPlaceholder = "{{.Count}}"
+)
+
+// EchoStream streams count responses, interval_ms apart, each the request
+// message with {{.Index}} and {{.Count}} replaced, so clients can exercise
+// stream consumption and flow control. The placeholders are substituted as
+// plain strings rather than run as a template, so a message cannot make the
+// server loop or grow its responses beyond the message itself. Sends block while the client is not reading
+// and the flow-control window is exhausted, and the stream ends early when
+// the client cancels the call.
+func (s *grpcEchoServer) EchoStream(req *echo.EchoStreamRequest, stream grpc.ServerStreamingServer[echo.EchoResponse]) error {
+	if err := s.checkReady("EchoStream"); err != nil {
+		return err
+	}
+
+	if req.GetCount() > maxStreamMessages {
+		return status.Errorf(codes.InvalidArgument, "count %d exceeds the maximum of %d", req.GetCount(), maxStreamMessages)
+	}
+	interval := time.Duration(req.GetIntervalMs()) * time.Millisecond
+	if interval > maxStreamInterval {
+		return status.Errorf(codes.InvalidArgument, "interval_ms %d exceeds the maximum of %d", req.GetIntervalMs(), maxStreamInterval.Milliseconds())
+	}
+
+	message := req.GetMessage()
+	if strings.Contains(strings.NewReplacer(streamIndexPlaceholder, "", streamCountPlaceholder, "").Replace(message), "{{") {
+		return status.Errorf(codes.InvalidArgument, "invalid message: only %s and %s placeholders are supported", streamIndexPlaceholder, streamCountPlaceholder)
+	}
+
+	ctx := stream.Context()
+	count := int(req.GetCount())
+
+	fmt.Printf("gRPC EchoStream called: %d message(s) every %s\n", count, interval)
+
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+	}
+
+	for i := 0; i < count; i++ {
+		if i > 0 && ticker != nil {
+			select {
+			case <-ctx.Done():
+				return status.FromContextError(ctx.Err()).Err()
+			case <-ticker.C:
+			}
+		}
+
+		r := strings.NewReplacer(streamIndexPlaceholder, strconv.Itoa(i), streamCountPlaceholder, strconv.Itoa(count))
+		if err := stream.Send(&echo.EchoResponse{Message: r.Replace(message)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	echo "http-echo/cmd/echo-server/grpc/generated"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// TestGRPCEchoStream verifies EchoStream sends the requested number of rendered messages at the requested pace
+func TestGRPCEchoStream(t *testing.T) {
+	conn, err := grpc.Dial(
+		grpcAddress,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to create gRPC client: %v", err)
+	}
+	defer conn.Close()
+
+	client := echo.NewEchoClient(conn)
+
+	t.Run("Count and pacing", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		start := time.Now()
+		stream, err := client.EchoStream(ctx, &echo.EchoStreamRequest{
+			Message:    "msg {{.Index}}/{{.Count}}",
+			Count:      5,
+			IntervalMs: 50,
+		})
+		if err != nil {
+			t.Fatalf("failed to call EchoStream: %v", err)
+		}
+
+		var messages []string
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("failed to receive: %v", err)
+			}
+			messages = append(messages, resp.GetMessage())
+		}
+		elapsed := time.Since(start)
+
+		if len(messages) != 5 {
+			t.Fatalf("expected 5 messages, got %d: %v", len(messages), messages)
+		}
+		for i, msg := range messages {
+			if want := fmt.Sprintf("msg %d/5", i); msg != want {
+				t.Errorf("expected message %d to be %q, got %q", i, want, msg)
+			}
+		}
+		if elapsed < 200*time.Millisecond || elapsed > 2*time.Second {
+			t.Errorf("expected the stream to take about 200ms, took %s", elapsed)
+		}
+	})
+
+	t.Run("Client cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stream, err := client.EchoStream(ctx, &echo.EchoStreamRequest{Message: "tick", Count: 1000, IntervalMs: 10})
+		if err != nil {
+			t.Fatalf("failed to call EchoStream: %v", err)
+		}
+		if _, err := stream.Recv(); err != nil {
+			t.Fatalf("failed to receive: %v", err)
+		}
+		cancel()
+
+		for {
+			if _, err = stream.Recv(); err != nil {
+				break
+			}
+		}
+		if status.Code(err) != codes.Canceled {
+			t.Errorf("expected Canceled after cancelling, got %v", err)
+		}
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		requests := map[string]*echo.EchoStreamRequest{
+			"Bad template":   {Message: "{{.Missing", Count: 1},
+			"Unknown field":  {Message: "{{.Host}}", Count: 1},
+			"Template logic": {Message: "{{range 1000000000}}xxxx{{end}}", Count: 1},
+			"Too many":       {Message: "x", Count: maxStreamMessages + 1},
+		}
+		for name, req := range requests {
+			t.Run(name, func(t *testing.T) {
+				stream, err := client.EchoStream(context.Background(), req)
+				if err == nil {
+					_, err = stream.Recv()
+				}
+				if status.Code(err) != codes.InvalidArgument {
+					t.Errorf("expected InvalidArgument, got %v", err)
+				}
+			})
+		}
+	})
+
+	t.Log("TestGRPCEchoStream passed")
+}
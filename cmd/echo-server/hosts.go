@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// allowedHost is an ALLOWED_HOSTS entry. An empty port matches any port.
+type allowedHost struct {
+	host string
+	port string
+}
+
+// splitHost splits a Host header or ALLOWED_HOSTS entry into its lowercase
+// host name, without brackets or a trailing dot, and its port, if any.
+func splitHost(hostport string) (host, port string) {
+	host = hostport
+	if h, p, err := net.SplitHostPort(hostport); err == nil {
+		host, port = h, p
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	return strings.ToLower(host), port
+}
+
+// allowedHosts returns the hosts requests may be addressed to, as configured
+// by the comma-separated ALLOWED_HOSTS. Entries are a host name or IP address,
+// which matches any port, a host and port such as "localhost:8080", or a
+// wildcard such as "*.example.com", which matches any subdomain. It returns
+// nil, allowing every host, when unset.
+func allowedHosts() ([]allowedHost, error) {
+	var hosts []allowedHost
+	for _, entry := range strings.Split(os.Getenv("ALLOWED_HOSTS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		host, port := splitHost(entry)
+		if host == "" || strings.ContainsAny(host, "/ ") || strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+			return nil, fmt.Errorf("ALLOWED_HOSTS: %q is not a valid host", entry)
+		}
+		hosts = append(hosts, allowedHost{host: host, port: port})
+	}
+	return hosts, nil
+}
+
+// matches reports whether the host and port of a request match the entry.
+func (a allowedHost) matches(host, port string) bool {
+	if a.port != "" && a.port != port {
+		return false
+	}
+	if suffix, ok := strings.CutPrefix(a.host, "*"); ok {
+		return strings.HasSuffix(host, suffix)
+	}
+	return a.host == host
+}
+
+// allowedHostsMiddleware rejects requests whose Host header is not allowed
+// by ALLOWED_HOSTS with 421 Misdirected Request, protecting against DNS
+// rebinding. Every host is allowed when ALLOWED_HOSTS is unset.
+func allowedHostsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts, _ := allowedHosts() // Validated at startup by validateConfig
+		if hosts == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		host, port := splitHost(r.Host)
+		for _, allowed := range hosts {
+			if allowed.matches(host, port) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		fmt.Printf("%s | host | rejected %s %s for Host %q\n", r.RemoteAddr, r.Method, r.URL, r.Host)
+		writeError(w, http.StatusMisdirectedRequest, fmt.Sprintf("Host %q is not allowed", r.Host))
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestAllowedHosts verifies requests to hosts outside ALLOWED_HOSTS are rejected with 421
+func TestAllowedHosts(t *testing.T) {
+	t.Setenv("ALLOWED_HOSTS", "localhost, api.example.com:8443, *.internal.example, [::1]")
+
+	tests := []struct {
+		host       string
+		wantStatus int
+	}{
+		{"localhost:" + testHTTPPort, http.StatusOK},
+		{"LOCALHOST", http.StatusOK},
+		{"api.example.com:8443", http.StatusOK},
+		{"api.example.com:9000", http.StatusMisdirectedRequest},
+		{"api.example.com", http.StatusMisdirectedRequest},
+		{"echo.internal.example", http.StatusOK},
+		{"internal.example", http.StatusMisdirectedRequest},
+		{"[::1]:8080", http.StatusOK},
+		{"attacker.example", http.StatusMisdirectedRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			req, err := http.NewRequest("GET", httpBaseURL+"/hosts", nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			req.Host = tt.host
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	t.Run("Invalid entry", func(t *testing.T) {
+		t.Setenv("ALLOWED_HOSTS", "example.com/path")
+		if _, err := allowedHosts(); err == nil {
+			t.Error("expected an error for a host with a path")
+		}
+	})
+
+	t.Log("TestAllowedHosts passed")
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// newHTTP3Server returns an HTTP/3 (QUIC) server listening on the UDP
+// address addr and serving handler with the given TLS configuration.
+func newHTTP3Server(addr string, handler http.Handler, cfg *tls.Config) *http3.Server {
+	h3Config := cfg.Clone()
+
+	// ClientHellos are only recorded for TCP connections, whose lifetime is
+	// reported by http.Server.ConnState.
+	h3Config.GetConfigForClient = nil
+
+	return &http3.Server{
+		Addr:      addr,
+		Handler:   handler,
+		TLSConfig: http3.ConfigureTLSConfig(h3Config),
+	}
+}
+
+// altSvcMiddleware advertises the HTTP/3 endpoint of h3 to HTTP/1.1 and
+// HTTP/2 clients via the Alt-Svc response header.
+func altSvcMiddleware(h3 *http3.Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor < 3 {
+			h3.SetQUICHeaders(w.Header()) // nolint:errcheck
+		}
+		next.ServeHTTP(w, r)
+	})
+}
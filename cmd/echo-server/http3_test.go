@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// TestHTTP3Support verifies requests over HTTP/3 and the Alt-Svc advertisement
+func TestHTTP3Support(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+	t.Setenv("TLS_CERT_FILE", certFile)
+	t.Setenv("TLS_KEY_FILE", keyFile)
+
+	cfg, err := newTLSConfig()
+	if err != nil {
+		t.Fatalf("failed to create TLS config: %v", err)
+	}
+
+	router := createRouter()
+
+	// HTTP/3 over UDP
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on UDP: %v", err)
+	}
+
+	h3 := newHTTP3Server("", router, cfg)
+	go h3.Serve(udpConn) // nolint:errcheck
+	defer h3.Close()
+
+	// HTTPS over TCP, advertising HTTP/3
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen on TCP: %v", err)
+	}
+
+	server := newTLSServer(altSvcMiddleware(h3, router), cfg)
+	go server.ServeTLS(lis, "", "") // nolint:errcheck
+	defer server.Close()
+
+	t.Run("Alt-Svc advertised", func(t *testing.T) {
+		resp, err := insecureTLSClient(nil).Get("https://" + lis.Addr().String() + "/alt-svc")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		_, port, _ := net.SplitHostPort(udpConn.LocalAddr().String())
+		if altSvc := resp.Header.Get("Alt-Svc"); !strings.Contains(altSvc, `h3=":`+port+`"`) {
+			t.Errorf("expected Alt-Svc to advertise h3 on port %s, got %q", port, altSvc)
+		}
+	})
+
+	t.Run("HTTP/3 request", func(t *testing.T) {
+		transport := &http3.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+		defer transport.Close()
+
+		client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+
+		resp, err := client.Get("https://" + udpConn.LocalAddr().String() + "/h3")
+		if err != nil {
+			t.Fatalf("failed to make HTTP/3 request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		if !strings.Contains(string(body), "GET /h3 HTTP/3.0") {
+			t.Errorf("expected HTTP/3.0 in response, got: %s", body)
+		}
+
+		if resp.Header.Get("Alt-Svc") != "" {
+			t.Error("expected no Alt-Svc header on HTTP/3 responses")
+		}
+	})
+
+	t.Log("TestHTTP3Support passed")
+}
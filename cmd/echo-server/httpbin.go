@@ -0,0 +1,474 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// httpbin-compatible endpoints. Responses follow the shapes returned by
+// https://httpbin.org so existing test suites can point at this server.
+
+// maxFormMemory is the maximum number of bytes of a multipart form kept in
+// memory while parsing.
+const maxFormMemory = 32 << 20
+
+// headersHandler handles GET /headers, returning only the request headers.
+func headersHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"headers": jsonHeaders(r),
+	})
+}
+
+// getHandler handles GET /get, returning the query arguments, headers, origin
+// and URL of the request.
+func getHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, httpbinRequest(r))
+}
+
+// postHandler handles POST /post. In addition to the /get fields it returns
+// the raw body as data, any parsed form fields and files, and the body
+// decoded as JSON when it is valid JSON.
+func postHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	result := httpbinRequest(r)
+	result["data"] = ""
+	result["files"] = map[string]interface{}{}
+	result["form"] = map[string]interface{}{}
+	result["json"] = nil
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err == nil {
+			result["form"] = jsonValues(r.PostForm)
+		}
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(maxFormMemory); err == nil {
+			result["form"] = jsonValues(r.MultipartForm.Value)
+			result["files"] = multipartFiles(r)
+		}
+	default:
+		result["data"] = string(body)
+
+		var decoded interface{}
+		if json.Unmarshal(body, &decoded) == nil {
+			result["json"] = decoded
+		}
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// defaultRobotsTxt is served by /robots.txt unless ROBOTS_TXT is set. It
+// disallows crawling the whole server.
+const defaultRobotsTxt = "User-agent: *\nDisallow: /\n"
+
+// robotsHandler handles GET /robots.txt, serving the robots policy from
+// ROBOTS_TXT. Since environment variables rarely hold newlines, a literal
+// "\n" in the value is treated as a line break.
+func robotsHandler(w http.ResponseWriter, r *http.Request) {
+	robots := defaultRobotsTxt
+	if v := os.Getenv("ROBOTS_TXT"); v != "" {
+		robots = strings.ReplaceAll(v, `\n`, "\n")
+		if !strings.HasSuffix(robots, "\n") {
+			robots += "\n"
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, robots) // nolint:errcheck
+}
+
+// denyHandler handles GET /deny, a page that robots.txt is expected to keep
+// well-behaved crawlers away from.
+func denyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusForbidden)
+	io.WriteString(w, "YOU SHOULDN'T BE HERE: this page is denied by /robots.txt\n") // nolint:errcheck
+}
+
+// base64DecodeHandler handles GET /base64/{value}, decoding the base64url
+// value. Padding is optional.
+func base64DecodeHandler(w http.ResponseWriter, r *http.Request) {
+	value := strings.TrimRight(mux.Vars(r)["value"], "=")
+
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid base64url value")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(decoded) // nolint:errcheck
+}
+
+// base64EncodeHandler handles GET /base64/encode, returning the "value"
+// query parameter encoded as base64url.
+func base64EncodeHandler(w http.ResponseWriter, r *http.Request) {
+	value, ok := r.URL.Query()["value"]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Missing value query parameter")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, base64.URLEncoding.EncodeToString([]byte(value[0]))) // nolint:errcheck
+}
+
+// maxStreamLines caps the number of lines sent by /stream/{n}.
+const maxStreamLines = 100
+
+// streamHandler handles GET /stream/{n}, streaming n (at most 100)
+// newline-delimited JSON objects, each the /get response with an "id" field
+// counting from 0. Every line is flushed as soon as it is written.
+func streamHandler(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(mux.Vars(r)["n"])
+	if err != nil || n < 0 {
+		writeError(w, http.StatusBadRequest, "Invalid number of lines")
+		return
+	}
+	n = min(n, maxStreamLines)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	result := httpbinRequest(r)
+
+	for i := 0; i < n; i++ {
+		if r.Context().Err() != nil {
+			return
+		}
+
+		result["id"] = i
+		if err := enc.Encode(result); err != nil {
+			return
+		}
+		rc.Flush() // nolint:errcheck
+	}
+}
+
+// maxBytes caps the size of the payload returned by /bytes/{n}.
+const maxBytes = 100 * 1024
+
+// bytesHandler handles GET /bytes/{n}, returning n (at most 100 KiB)
+// pseudo-random bytes generated from the "seed" query parameter (default 0).
+// The payload is the same for every request with the same n and seed, so
+// byte-range requests are honored with 206 Partial Content, for testing
+// ranged and resumed downloads. With ?fail-at=m, the response is aborted
+// by failBytes after m bytes.
+func bytesHandler(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(mux.Vars(r)["n"])
+	if err != nil || n < 0 || n > maxBytes {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid number of bytes (0-%d)", maxBytes))
+		return
+	}
+
+	var seed uint64
+	if v := r.URL.Query().Get("seed"); v != "" {
+		if seed, err = strconv.ParseUint(v, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid seed %q", v))
+			return
+		}
+	}
+
+	failAt := -1
+	if v := r.URL.Query().Get("fail-at"); v != "" {
+		if failAt, err = strconv.Atoi(v); err != nil || failAt < 0 || failAt >= n {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid fail-at %q, must be less than %d", v, n))
+			return
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(seed, seed))
+	payload := make([]byte, n)
+	for i := range payload {
+		payload[i] = byte(rng.Uint32())
+	}
+
+	if failAt >= 0 {
+		failBytes(w, r, payload, failAt)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(payload))
+}
+
+// failBytes starts a 200 response announcing the whole payload, ignoring any
+// Range, and closes the connection after failAt bytes of it, to test clients
+// handling truncated downloads.
+//
+// Unlike injectReset, HTTP/1.x connections are closed cleanly, so the client
+// sees an unexpected EOF rather than a TCP RST. HTTP/2 connections cannot be
+// hijacked, so the stream is reset with RST_STREAM after failAt bytes.
+func failBytes(w http.ResponseWriter, r *http.Request, payload []byte, failAt int) {
+	fmt.Printf("%s | fail-at | closing connection after %d of %d byte(s)\n", r.RemoteAddr, failAt, len(payload))
+
+	rc := http.NewResponseController(w)
+	conn, buf, err := rc.Hijack()
+	if err != nil {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		w.Write(payload[:failAt]) // nolint:errcheck
+		rc.Flush()                // nolint:errcheck
+
+		// Resets the HTTP/2 stream; recoverMiddleware lets it through.
+		panic(http.ErrAbortHandler)
+	}
+
+	fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Type: application/octet-stream\r\nContent-Length: %d\r\n\r\n", len(payload))
+	buf.Write(payload[:failAt]) // nolint:errcheck
+	buf.Flush()                 // nolint:errcheck
+	conn.Close()
+}
+
+// cookiesHandler handles GET /cookies, returning the request cookies as
+// {"cookies": {name: value}}.
+func cookiesHandler(w http.ResponseWriter, r *http.Request) {
+	cookies := map[string]string{}
+	for _, c := range r.Cookies() {
+		cookies[c.Name] = c.Value
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"cookies": cookies})
+}
+
+// setCookiesHandler handles GET /cookies/set?name=value, setting a cookie for
+// every query parameter and redirecting to /cookies.
+func setCookiesHandler(w http.ResponseWriter, r *http.Request) {
+	var cookies []*http.Cookie
+	for name, values := range r.URL.Query() {
+		cookies = append(cookies, &http.Cookie{Name: name, Value: values[0], Path: "/"})
+	}
+
+	redirectWithCookies(w, r, cookies)
+}
+
+// deleteCookiesHandler handles GET /cookies/delete?name, expiring the cookie
+// named by every query parameter and redirecting to /cookies.
+func deleteCookiesHandler(w http.ResponseWriter, r *http.Request) {
+	var cookies []*http.Cookie
+	for name := range r.URL.Query() {
+		cookies = append(cookies, &http.Cookie{Name: name, Path: "/", MaxAge: -1})
+	}
+
+	redirectWithCookies(w, r, cookies)
+}
+
+// redirectWithCookies sets cookies and redirects to /cookies, or fails with
+// 400 if any cookie is invalid.
+func redirectWithCookies(w http.ResponseWriter, r *http.Request, cookies []*http.Cookie) {
+	for _, c := range cookies {
+		if err := c.Valid(); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid cookie %q", c.Name))
+			return
+		}
+	}
+
+	for _, c := range cookies {
+		http.SetCookie(w, c)
+	}
+	http.Redirect(w, r, "/cookies", http.StatusFound)
+}
+
+// maxLinks caps the number of links on a /links page.
+const maxLinks = 200
+
+// linksRedirectHandler handles GET /links/{n}, redirecting to the first page
+// of the set, /links/{n}/0.
+func linksRedirectHandler(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, fmt.Sprintf("/links/%s/0", mux.Vars(r)["n"]), http.StatusFound)
+}
+
+// linksHandler handles GET /links/{n}/{offset}, returning an HTML page with
+// links to each of the n (at most 200) pages of the set except the current
+// one, numbered from 0.
+func linksHandler(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(mux.Vars(r)["n"])
+	if err != nil || n < 0 {
+		writeError(w, http.StatusBadRequest, "Invalid number of links")
+		return
+	}
+	offset, err := strconv.Atoi(mux.Vars(r)["offset"])
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusBadRequest, "Invalid offset")
+		return
+	}
+	n = min(n, maxLinks)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	var b strings.Builder
+	b.WriteString("<html><head><title>Links</title></head><body>")
+	for i := 0; i < n; i++ {
+		if i == offset {
+			fmt.Fprintf(&b, "%d ", i)
+		} else {
+			fmt.Fprintf(&b, "<a href='/links/%d/%d'>%d</a> ", n, i, i)
+		}
+	}
+	b.WriteString("</body></html>\n")
+
+	io.WriteString(w, b.String()) // nolint:errcheck
+}
+
+// httpbinRequest returns the fields common to the httpbin request-inspection
+// endpoints.
+func httpbinRequest(r *http.Request) map[string]interface{} {
+	return map[string]interface{}{
+		"args":    jsonValues(r.URL.Query()),
+		"headers": jsonHeaders(r),
+		"origin":  clientIP(r),
+		"url":     requestURL(r),
+	}
+}
+
+// multipartFiles returns the contents of the uploaded files of a parsed
+// multipart form, keyed by form field name.
+func multipartFiles(r *http.Request) map[string]interface{} {
+	files := map[string]interface{}{}
+
+	for name, headers := range r.MultipartForm.File {
+		contents := make([]string, 0, len(headers))
+		for _, fh := range headers {
+			f, err := fh.Open()
+			if err != nil {
+				continue
+			}
+			data, _ := io.ReadAll(f)
+			f.Close()
+			contents = append(contents, string(data))
+		}
+
+		if len(contents) == 1 {
+			files[name] = contents[0]
+		} else {
+			files[name] = contents
+		}
+	}
+
+	return files
+}
+
+// clientIP returns the address of the client that made the request. The first
+// address in X-Forwarded-For is used when present and the peer is trusted by
+// TRUST_PROXY, otherwise the host part of the connection's remote address, so
+// that untrusted clients cannot pass themselves off as others.
+func clientIP(r *http.Request) string {
+	if isTrustedProxy(r) {
+		if ip := firstHeaderValue(r.Header, "X-Forwarded-For"); ip != "" {
+			return ip
+		}
+	}
+
+	return remoteHost(r)
+}
+
+// accountingClientIP returns the client IP that per-client budgets and
+// counts are kept for. It is clientIP when TRUST_PROXY is set, and otherwise
+// always the connection's remote address, as the default of trusting any
+// peer would let every client pick a fresh identity with X-Forwarded-For.
+func accountingClientIP(r *http.Request) string {
+	if os.Getenv("TRUST_PROXY") == "" {
+		return remoteHost(r)
+	}
+	return clientIP(r)
+}
+
+// remoteHost returns the host part of the connection's remote address.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// requestURL returns the absolute URL of the request.
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	u := url.URL{
+		Scheme:   scheme,
+		Host:     r.Host,
+		Path:     r.URL.Path,
+		RawPath:  r.URL.RawPath,
+		RawQuery: r.URL.RawQuery,
+	}
+	return u.String()
+}
+
+// jsonValues converts query or form values into a JSON-friendly map, using a
+// string for single values and an array for repeated ones.
+func jsonValues(values url.Values) map[string]interface{} {
+	result := make(map[string]interface{}, len(values))
+
+	for key, v := range values {
+		if len(v) == 1 {
+			result[key] = v[0]
+		} else {
+			result[key] = v
+		}
+	}
+
+	return result
+}
+
+// jsonHeaders converts the request headers into a JSON-friendly map. Headers
+// with a single value are represented as a string, multi-value headers as an
+// array of strings.
+func jsonHeaders(r *http.Request) map[string]interface{} {
+	headers := make(map[string]interface{}, len(r.Header)+1)
+	headers["Host"] = r.Host
+
+	for key, values := range r.Header {
+		if len(values) == 1 {
+			headers[key] = values[0]
+		} else {
+			headers[key] = values
+		}
+	}
+
+	return headers
+}
+
+// writeJSON writes v as an indented JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	writeJSONBody(w, code, v)
+}
+
+// writeJSONBody writes v as an indented JSON response with the given status
+// code, leaving the Content-Type to the caller.
+func writeJSONBody(w http.ResponseWriter, code int, v interface{}) {
+	w.WriteHeader(code)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v) // nolint:errcheck
+}
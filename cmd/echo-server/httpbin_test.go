@@ -0,0 +1,542 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestHeadersEndpoint verifies the httpbin-compatible /headers endpoint
+func TestHeadersEndpoint(t *testing.T) {
+	req, err := http.NewRequest("GET", httpBaseURL+"/headers", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("X-Custom-Header", "test-value")
+	req.Header.Add("X-Multi", "one")
+	req.Header.Add("X-Multi", "two")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %s", ct)
+	}
+
+	var result struct {
+		Headers map[string]interface{} `json:"headers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if v := result.Headers["X-Custom-Header"]; v != "test-value" {
+		t.Errorf("expected X-Custom-Header 'test-value', got %v", v)
+	}
+
+	multi, ok := result.Headers["X-Multi"].([]interface{})
+	if !ok || len(multi) != 2 {
+		t.Errorf("expected X-Multi to be an array of 2 values, got %v", result.Headers["X-Multi"])
+	}
+
+	t.Log("TestHeadersEndpoint passed")
+}
+
+// TestGetEndpoint verifies the httpbin-compatible /get endpoint
+func TestGetEndpoint(t *testing.T) {
+	req, err := http.NewRequest("GET", httpBaseURL+"/get?foo=bar&multi=1&multi=2", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	// Same top-level keys as httpbin's /get
+	assertJSONKeys(t, result, "args", "headers", "origin", "url")
+
+	args, _ := result["args"].(map[string]interface{})
+	if args["foo"] != "bar" {
+		t.Errorf("expected args.foo 'bar', got %v", args["foo"])
+	}
+	if multi, ok := args["multi"].([]interface{}); !ok || len(multi) != 2 {
+		t.Errorf("expected args.multi to be an array of 2 values, got %v", args["multi"])
+	}
+
+	if result["origin"] != "203.0.113.7" {
+		t.Errorf("expected origin '203.0.113.7', got %v", result["origin"])
+	}
+
+	if u, _ := result["url"].(string); !strings.HasPrefix(u, "http://localhost:"+testHTTPPort+"/get?") {
+		t.Errorf("unexpected url %q", u)
+	}
+
+	t.Log("TestGetEndpoint passed")
+}
+
+// TestPostEndpoint verifies the httpbin-compatible /post endpoint
+func TestPostEndpoint(t *testing.T) {
+	t.Run("JSON body", func(t *testing.T) {
+		resp, err := http.Post(httpBaseURL+"/post", "application/json", strings.NewReader(`{"message":"hello"}`))
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		// Same top-level keys as httpbin's /post
+		assertJSONKeys(t, result, "args", "data", "files", "form", "headers", "json", "origin", "url")
+
+		if result["data"] != `{"message":"hello"}` {
+			t.Errorf("unexpected data %v", result["data"])
+		}
+
+		decoded, _ := result["json"].(map[string]interface{})
+		if decoded["message"] != "hello" {
+			t.Errorf("expected json.message 'hello', got %v", result["json"])
+		}
+	})
+
+	t.Run("Form body", func(t *testing.T) {
+		form := url.Values{"name": {"Joe"}}
+		resp, err := http.PostForm(httpBaseURL+"/post", form)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		parsed, _ := result["form"].(map[string]interface{})
+		if parsed["name"] != "Joe" {
+			t.Errorf("expected form.name 'Joe', got %v", result["form"])
+		}
+
+		if result["json"] != nil {
+			t.Errorf("expected json to be null, got %v", result["json"])
+		}
+	})
+
+	t.Log("TestPostEndpoint passed")
+}
+
+// assertJSONKeys fails the test unless m has exactly the given keys.
+func assertJSONKeys(t *testing.T, m map[string]interface{}, keys ...string) {
+	t.Helper()
+
+	if len(m) != len(keys) {
+		t.Errorf("expected keys %v, got %v", keys, m)
+	}
+
+	for _, key := range keys {
+		if _, ok := m[key]; !ok {
+			t.Errorf("expected key %q in response", key)
+		}
+	}
+}
+
+// TestRobotsAndDeny verifies the /robots.txt and /deny endpoints
+func TestRobotsAndDeny(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		robotsTxt  string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Default robots policy", "/robots.txt", "", http.StatusOK, "User-agent: *\nDisallow: /\n"},
+		{"Configured robots policy", "/robots.txt", `User-agent: *\nDisallow: /deny`, http.StatusOK, "User-agent: *\nDisallow: /deny\n"},
+		{"Denied page", "/deny", "", http.StatusForbidden, "YOU SHOULDN'T BE HERE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ROBOTS_TXT", tt.robotsTxt)
+
+			resp, err := http.Get(httpBaseURL + tt.path)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			if ct := resp.Header.Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+				t.Errorf("expected Content-Type text/plain; charset=utf-8, got %s", ct)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			if !strings.HasPrefix(string(body), tt.wantBody) {
+				t.Errorf("expected body to start with %q, got %q", tt.wantBody, body)
+			}
+		})
+	}
+
+	t.Log("TestRobotsAndDeny passed")
+}
+
+// TestBase64Endpoints verifies base64url encoding and decoding round-trip
+func TestBase64Endpoints(t *testing.T) {
+	get := func(t *testing.T, path string) (int, string) {
+		t.Helper()
+
+		resp, err := http.Get(httpBaseURL + path)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+		return resp.StatusCode, string(body)
+	}
+
+	t.Run("Round trip", func(t *testing.T) {
+		const value = "hello, echo?>"
+
+		code, encoded := get(t, "/base64/encode?value="+url.QueryEscape(value))
+		if code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", code)
+		}
+		if encoded != "aGVsbG8sIGVjaG8_Pg==" {
+			t.Errorf("unexpected encoding %q", encoded)
+		}
+
+		code, decoded := get(t, "/base64/"+encoded)
+		if code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", code)
+		}
+		if decoded != value {
+			t.Errorf("expected %q, got %q", value, decoded)
+		}
+	})
+
+	t.Run("Unpadded value", func(t *testing.T) {
+		if _, decoded := get(t, "/base64/aGVsbG8"); decoded != "hello" {
+			t.Errorf("expected %q, got %q", "hello", decoded)
+		}
+	})
+
+	t.Run("Malformed value", func(t *testing.T) {
+		if code, _ := get(t, "/base64/not*base64"); code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", code)
+		}
+	})
+
+	t.Run("Missing value", func(t *testing.T) {
+		if code, _ := get(t, "/base64/encode"); code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", code)
+		}
+	})
+
+	t.Log("TestBase64Endpoints passed")
+}
+
+// TestStreamEndpoint verifies /stream/{n} sends n JSON lines
+func TestStreamEndpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		n          string
+		wantStatus int
+		wantLines  int
+	}{
+		{"Five lines", "5", http.StatusOK, 5},
+		{"Capped", "1000", http.StatusOK, 100},
+		{"Invalid", "abc", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(httpBaseURL + "/stream/" + tt.n)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+				t.Errorf("expected Content-Type application/x-ndjson, got %s", ct)
+			}
+
+			dec := json.NewDecoder(resp.Body)
+			lines := 0
+			for dec.More() {
+				var line map[string]interface{}
+				if err := dec.Decode(&line); err != nil {
+					t.Fatalf("failed to decode line %d: %v", lines, err)
+				}
+
+				if id, _ := line["id"].(float64); int(id) != lines {
+					t.Errorf("expected id %d, got %v", lines, line["id"])
+				}
+				if line["url"] == nil {
+					t.Errorf("expected line %d to echo the request url", lines)
+				}
+				lines++
+			}
+
+			if lines != tt.wantLines {
+				t.Errorf("expected %d lines, got %d", tt.wantLines, lines)
+			}
+		})
+	}
+
+	t.Log("TestStreamEndpoint passed")
+}
+
+// TestBytesEndpoint verifies /bytes/{n} returns a stable payload and honors byte ranges
+func TestBytesEndpoint(t *testing.T) {
+	get := func(t *testing.T, path, rangeHeader string) (*http.Response, []byte) {
+		t.Helper()
+
+		req, err := http.NewRequest("GET", httpBaseURL+path, nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+		return resp, body
+	}
+
+	resp, full := get(t, "/bytes/1000?seed=42", "")
+	if resp.StatusCode != http.StatusOK || len(full) != 1000 {
+		t.Fatalf("expected 200 with 1000 bytes, got %d with %d bytes", resp.StatusCode, len(full))
+	}
+	if resp.Header.Get("Accept-Ranges") != "bytes" {
+		t.Errorf("expected Accept-Ranges: bytes, got %q", resp.Header.Get("Accept-Ranges"))
+	}
+
+	t.Run("Stable payload", func(t *testing.T) {
+		if _, again := get(t, "/bytes/1000?seed=42", ""); string(again) != string(full) {
+			t.Error("expected the same payload for the same seed")
+		}
+		if _, other := get(t, "/bytes/1000?seed=7", ""); string(other) == string(full) {
+			t.Error("expected a different payload for another seed")
+		}
+	})
+
+	t.Run("Sub-range", func(t *testing.T) {
+		resp, part := get(t, "/bytes/1000?seed=42", "bytes=100-199")
+
+		if resp.StatusCode != http.StatusPartialContent {
+			t.Fatalf("expected status 206, got %d", resp.StatusCode)
+		}
+		if cr := resp.Header.Get("Content-Range"); cr != "bytes 100-199/1000" {
+			t.Errorf("expected Content-Range bytes 100-199/1000, got %q", cr)
+		}
+		if string(part) != string(full[100:200]) {
+			t.Error("expected the range to match the slice of the full payload")
+		}
+	})
+
+	t.Run("Unsatisfiable range", func(t *testing.T) {
+		if resp, _ := get(t, "/bytes/1000", "bytes=2000-"); resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+			t.Errorf("expected status 416, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Too large", func(t *testing.T) {
+		if resp, _ := get(t, "/bytes/999999", ""); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Fail at", func(t *testing.T) {
+		resp, err := http.Get(httpBaseURL + "/bytes/1000?seed=42&fail-at=300")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || resp.ContentLength != 1000 {
+			t.Fatalf("expected 200 announcing 1000 bytes, got %d announcing %d", resp.StatusCode, resp.ContentLength)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("expected an unexpected EOF, got %v", err)
+		}
+		if len(body) >= 1000 || string(body) != string(full[:300]) {
+			t.Errorf("expected the first 300 bytes of the payload, got %d bytes", len(body))
+		}
+	})
+
+	t.Run("Invalid fail at", func(t *testing.T) {
+		for _, failAt := range []string{"1000", "-1", "abc"} {
+			if resp, _ := get(t, "/bytes/1000?fail-at="+failAt, ""); resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("fail-at=%s: expected status 400, got %d", failAt, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Log("TestBytesEndpoint passed")
+}
+
+// TestLinksEndpoint verifies /links/{n}/{offset} links to every other page of the set
+func TestLinksEndpoint(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		wantStatus  int
+		wantAnchors int
+	}{
+		{"First page", "/links/10/0", http.StatusOK, 9},
+		{"Offset past the end", "/links/5/7", http.StatusOK, 5},
+		{"Redirect to first page", "/links/3", http.StatusOK, 2},
+		{"Capped", "/links/1000/0", http.StatusOK, maxLinks - 1},
+		{"Invalid", "/links/abc/0", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(httpBaseURL + tt.path)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if ct := resp.Header.Get("Content-Type"); ct != "text/html; charset=utf-8" {
+				t.Errorf("expected Content-Type text/html; charset=utf-8, got %s", ct)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			if anchors := strings.Count(string(body), "<a href="); anchors != tt.wantAnchors {
+				t.Errorf("expected %d anchors, got %d: %s", tt.wantAnchors, anchors, body)
+			}
+		})
+	}
+
+	t.Log("TestLinksEndpoint passed")
+}
+
+// TestCookiesEndpoints verifies cookies set and deleted through /cookies are reflected by the client jar
+func TestCookiesEndpoints(t *testing.T) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("failed to create cookie jar: %v", err)
+	}
+	client := &http.Client{Jar: jar}
+
+	getCookies := func(t *testing.T, path string) map[string]interface{} {
+		t.Helper()
+
+		resp, err := client.Get(httpBaseURL + path)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		if resp.Request.URL.Path != "/cookies" {
+			t.Errorf("expected to end up at /cookies, got %s", resp.Request.URL.Path)
+		}
+
+		var result struct {
+			Cookies map[string]interface{} `json:"cookies"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return result.Cookies
+	}
+
+	t.Run("Set", func(t *testing.T) {
+		cookies := getCookies(t, "/cookies/set?flavor=chocolate&size=large")
+		if cookies["flavor"] != "chocolate" || cookies["size"] != "large" {
+			t.Errorf("expected the set cookies to be reflected, got %v", cookies)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		cookies := getCookies(t, "/cookies/delete?flavor")
+		if _, ok := cookies["flavor"]; ok {
+			t.Errorf("expected flavor to be deleted, got %v", cookies)
+		}
+		if cookies["size"] != "large" {
+			t.Errorf("expected size to be kept, got %v", cookies)
+		}
+	})
+
+	t.Run("Invalid name", func(t *testing.T) {
+		resp, err := http.Get(httpBaseURL + "/cookies/set?" + url.QueryEscape("bad name") + "=1")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Log("TestCookiesEndpoints passed")
+}
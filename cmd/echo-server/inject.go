@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// wsSessionRegistry maps session ids to the WebSocket connections that
+// joined them with the "session" query parameter.
+type wsSessionRegistry struct {
+	mu       sync.Mutex
+	sessions map[string]*wsWriter
+}
+
+// wsSessions holds the WebSocket connections that can receive messages
+// posted to /inject/{sessionId}.
+var wsSessions = &wsSessionRegistry{sessions: map[string]*wsWriter{}}
+
+// register associates the session id with a connection, replacing any
+// earlier connection with the same id. It returns a function that removes
+// the association, to be called when the connection ends.
+func (r *wsSessionRegistry) register(id string, w *wsWriter) func() {
+	r.mu.Lock()
+	r.sessions[id] = w
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		// A newer connection may have taken over the session
+		if r.sessions[id] == w {
+			delete(r.sessions, id)
+		}
+	}
+}
+
+// lookup returns the connection of the session id, if one is connected.
+func (r *wsSessionRegistry) lookup(id string) (*wsWriter, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w, ok := r.sessions[id]
+	return w, ok
+}
+
+// injectHandler handles POST /inject/{sessionId}, pushing the request body
+// to the WebSocket client connected with ?session={sessionId}, as a text
+// message when the body is valid UTF-8 and a binary one otherwise. The
+// body is limited to MAX_BODY_BYTES when set.
+func injectHandler(w http.ResponseWriter, r *http.Request) {
+	session := mux.Vars(r)["sessionId"]
+
+	body := io.Reader(r.Body)
+	if maxBytes, _ := envInt("MAX_BODY_BYTES"); maxBytes > 0 {
+		body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
+	}
+
+	data, err := io.ReadAll(body)
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds the maximum of %d bytes", maxBytesErr.Limit))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	writer, ok := wsSessions.lookup(session)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No WebSocket connected for session %q", session))
+		return
+	}
+
+	messageType := websocket.BinaryMessage
+	if utf8.Valid(data) {
+		messageType = websocket.TextMessage
+	}
+
+	if err := writer.WriteMessage(messageType, data); err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("Failed to deliver to session %q: %v", session, err))
+		return
+	}
+
+	fmt.Printf("%s | inject | %d byte(s) to session %s\n", r.RemoteAddr, len(data), session)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"session": session,
+		"bytes":   len(data),
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestInjectToWebSocket verifies messages posted over HTTP are pushed to the session's WebSocket
+func TestInjectToWebSocket(t *testing.T) {
+	conn, _, err := websocket.DefaultDialer.Dial("ws://localhost:"+testHTTPPort+"/ws?session=abc", nil)
+	if err != nil {
+		t.Fatalf("failed to connect to WebSocket: %v", err)
+	}
+	defer conn.Close()
+
+	// Skip the greeting
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("failed to read greeting: %v", err)
+	}
+
+	t.Run("Connected session", func(t *testing.T) {
+		resp, err := http.Post(httpBaseURL+"/inject/abc", "text/plain", strings.NewReader("pushed"))
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second)) // nolint:errcheck
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("failed to read pushed message: %v", err)
+		}
+		if messageType != websocket.TextMessage || string(message) != "pushed" {
+			t.Errorf("expected text message %q, got type %d %q", "pushed", messageType, message)
+		}
+	})
+
+	t.Run("Echo still works", func(t *testing.T) {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+			t.Fatalf("failed to write message: %v", err)
+		}
+
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("failed to read echo: %v", err)
+		}
+		if string(message) != "hello" {
+			t.Errorf("expected echo %q, got %q", "hello", message)
+		}
+	})
+
+	t.Run("Unknown session", func(t *testing.T) {
+		resp, err := http.Post(httpBaseURL+"/inject/nobody", "text/plain", strings.NewReader("lost"))
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Disconnected session", func(t *testing.T) {
+		conn.Close()
+
+		deadline := time.Now().Add(3 * time.Second)
+		for {
+			if _, ok := wsSessions.lookup("abc"); !ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("expected the session to be removed after disconnect")
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	})
+
+	t.Log("TestInjectToWebSocket passed")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/itchyny/gojq"
+)
+
+// jqTimeout bounds how long a jq expression may run, since expressions such
+// as "repeat(.)" never finish on their own.
+const jqTimeout = time.Second
+
+// maxJQResults caps the number of values a jq expression may produce.
+const maxJQResults = 1000
+
+// jqExpression returns the jq expression in the request's "jq" query
+// parameter, if ENABLE_JQ is set and one was given.
+func jqExpression(req *http.Request) (string, bool) {
+	if !envBool("ENABLE_JQ") {
+		return "", false
+	}
+	v, ok := req.URL.Query()["jq"]
+	if !ok {
+		return "", false
+	}
+	return v[0], true
+}
+
+// serveJQ responds with the JSON request body transformed by the jq
+// expression. Each value the expression produces is written as an indented
+// JSON document, as the jq command line tool does. The body is limited to
+// MAX_BODY_BYTES when set.
+func serveJQ(wr http.ResponseWriter, req *http.Request, expression string) {
+	query, err := gojq.Parse(expression)
+	if err != nil {
+		writeError(wr, http.StatusBadRequest, fmt.Sprintf("Invalid jq expression: %v", err))
+		return
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		writeError(wr, http.StatusBadRequest, fmt.Sprintf("Invalid jq expression: %v", err))
+		return
+	}
+
+	body := io.Reader(req.Body)
+	if maxBytes, _ := envInt("MAX_BODY_BYTES"); maxBytes > 0 {
+		body = http.MaxBytesReader(wr, req.Body, int64(maxBytes))
+	}
+
+	data, err := io.ReadAll(body)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeError(wr, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds the maximum of %d bytes", maxBytesErr.Limit))
+		return
+	}
+	if err != nil {
+		writeError(wr, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	// gojq only accepts the types produced by decoding into interface{}
+	var input interface{}
+	if err := json.Unmarshal(data, &input); err != nil {
+		writeError(wr, http.StatusBadRequest, "Request body is not valid JSON")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), jqTimeout)
+	defer cancel()
+
+	// Run the expression to completion before writing, so errors can still
+	// be reported with a 400.
+	var results []interface{}
+	iter := code.RunWithContext(ctx, input)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := v.(error); ok {
+			var haltErr *gojq.HaltError
+			if errors.As(err, &haltErr) && haltErr.Value() == nil {
+				break
+			}
+			writeError(wr, http.StatusBadRequest, fmt.Sprintf("jq evaluation failed: %v", err))
+			return
+		}
+		if len(results) == maxJQResults {
+			writeError(wr, http.StatusBadRequest, fmt.Sprintf("jq expression produced more than %d results", maxJQResults))
+			return
+		}
+		results = append(results, v)
+	}
+
+	wr.Header().Set("Content-Type", "application/json")
+	wr.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(wr)
+	enc.SetIndent("", "  ")
+	for _, v := range results {
+		enc.Encode(v) // nolint:errcheck
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestJQTransform verifies that ?jq= transforms the JSON request body when ENABLE_JQ is set
+func TestJQTransform(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    string
+		expression string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Field selector", "true", ".message", `{"message":"hello","id":1}`, http.StatusOK, "\"hello\"\n"},
+		{"Multiple results", "true", ".[]", `[1,2]`, http.StatusOK, "1\n2\n"},
+		{"Invalid expression", "true", ".message |", `{"message":"hello"}`, http.StatusBadRequest, ""},
+		{"Non-JSON body", "true", ".message", `message=hello`, http.StatusBadRequest, ""},
+		{"Runaway expression", "true", "repeat(.)", `1`, http.StatusBadRequest, ""},
+		{"Disabled", "", ".message", `{"message":"hello"}`, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENABLE_JQ", tt.enabled)
+
+			resp, err := http.Post(httpBaseURL+"/?jq="+url.QueryEscape(tt.expression), "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+
+			if tt.enabled == "" {
+				if !strings.Contains(string(body), tt.body) {
+					t.Errorf("expected the request to be echoed, got: %s", body)
+				}
+				return
+			}
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, body)
+			}
+		})
+	}
+
+	t.Log("TestJQTransform passed")
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// checkRequestLimits rejects requests exceeding the configured limits. It
+// reports whether the request may proceed; when it returns false an error
+// response has already been written.
+func checkRequestLimits(wr http.ResponseWriter, req *http.Request) bool {
+	if maxLength, _ := envInt("MAX_URL_LENGTH"); maxLength > 0 {
+		if length := len(req.URL.RequestURI()); length > maxLength {
+			fmt.Printf("%s | rejected | URL length %d exceeds %d\n", req.RemoteAddr, length, maxLength)
+			writeError(wr, http.StatusRequestURITooLong, fmt.Sprintf("URI length %d exceeds the maximum of %d", length, maxLength))
+			return false
+		}
+	}
+
+	if maxParams, _ := envInt("MAX_QUERY_PARAMS"); maxParams > 0 {
+		if count := len(req.URL.Query()); count > maxParams {
+			fmt.Printf("%s | rejected | %d query parameters exceed %d\n", req.RemoteAddr, count, maxParams)
+			writeError(wr, http.StatusBadRequest, fmt.Sprintf("%d query parameters exceed the maximum of %d", count, maxParams))
+			return false
+		}
+	}
+
+	if maxLength, _ := envInt("MAX_HEADER_VALUE_LENGTH"); maxLength > 0 {
+		for name, values := range req.Header {
+			for _, value := range values {
+				if length := len(value); length > maxLength {
+					fmt.Printf("%s | rejected | %s header length %d exceeds %d\n", req.RemoteAddr, name, length, maxLength)
+					writeError(wr, http.StatusRequestHeaderFieldsTooLarge, fmt.Sprintf("%s header length %d exceeds the maximum of %d", name, length, maxLength))
+					return false
+				}
+			}
+		}
+	}
+
+	return true
+}
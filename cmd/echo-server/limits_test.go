@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestMaxURLLength verifies over-length URLs are rejected with 414
+func TestMaxURLLength(t *testing.T) {
+	t.Setenv("MAX_URL_LENGTH", "64")
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{"within limit", "/short", http.StatusOK},
+		{"over limit", "/" + strings.Repeat("a", 100), http.StatusRequestURITooLong},
+		{"long query", "/short?q=" + strings.Repeat("b", 100), http.StatusRequestURITooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(httpBaseURL + tt.path)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			if tt.wantStatus == http.StatusRequestURITooLong {
+				var body map[string]string
+				if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if body["error"] == "" {
+					t.Error("expected error message in response")
+				}
+			}
+		})
+	}
+
+	t.Log("TestMaxURLLength passed")
+}
+
+// TestMaxHeaderValueLength verifies over-length header values are rejected with 431
+func TestMaxHeaderValueLength(t *testing.T) {
+	t.Setenv("MAX_HEADER_VALUE_LENGTH", "32")
+
+	tests := []struct {
+		name       string
+		value      string
+		wantStatus int
+	}{
+		{"within limit", strings.Repeat("a", 32), http.StatusOK},
+		{"over limit", strings.Repeat("a", 33), http.StatusRequestHeaderFieldsTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", httpBaseURL+"/", nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			req.Header.Set("X-Long", tt.value)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			if tt.wantStatus == http.StatusRequestHeaderFieldsTooLarge {
+				var body map[string]string
+				if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if !strings.Contains(body["error"], "X-Long") {
+					t.Errorf("expected error to name the header, got %q", body["error"])
+				}
+			}
+		})
+	}
+
+	t.Log("TestMaxHeaderValueLength passed")
+}
+
+// TestMaxQueryParams verifies requests with too many query parameters are rejected with 400
+func TestMaxQueryParams(t *testing.T) {
+	t.Setenv("MAX_QUERY_PARAMS", "3")
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{"within limit", "a=1&b=2&c=3", http.StatusOK},
+		{"repeated names count once", "a=1&a=2&a=3&a=4", http.StatusOK},
+		{"over limit", "a=1&b=2&c=3&d=4", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(httpBaseURL + "/params?" + tt.query)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			if tt.wantStatus == http.StatusBadRequest {
+				var body map[string]string
+				if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if body["error"] == "" {
+					t.Error("expected error message in response")
+				}
+			}
+		})
+	}
+
+	t.Log("TestMaxQueryParams passed")
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// listen announces on the TCP address addr, applying the listener settings
+// from the environment:
+//
+//   - LISTEN_REUSEPORT sets SO_REUSEPORT on the socket (Linux only)
+//   - LISTEN_BACKLOG sets the accept queue length (Linux only)
+//   - ACCEPT_RATE limits how many connections are accepted per second
+//   - PROXY_PROTOCOL reads client addresses from PROXY protocol headers
+func listen(addr string) (net.Listener, error) {
+	lc := net.ListenConfig{}
+	if envBool("LISTEN_REUSEPORT") {
+		lc.Control = reusePortControl
+	}
+
+	lis, err := lc.Listen(context.Background(), "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Settings are validated at startup by validateConfig
+	if backlog, _ := envInt("LISTEN_BACKLOG"); backlog > 0 {
+		if err := setListenBacklog(lis, backlog); err != nil {
+			lis.Close()
+			return nil, err
+		}
+	}
+
+	if rate, _ := envInt("ACCEPT_RATE"); rate > 0 {
+		lis = &throttledListener{Listener: lis, interval: time.Second / time.Duration(rate)}
+	}
+
+	if envBool("PROXY_PROTOCOL") {
+		lis = proxyProtocolListener(lis)
+	}
+	return lis, nil
+}
+
+// throttledListener accepts at most one connection per interval, leaving
+// the others waiting in the kernel's accept queue, to simulate a server that
+// accepts connections slowly.
+type throttledListener struct {
+	net.Listener
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (l *throttledListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	time.Sleep(time.Until(l.next))
+
+	conn, err := l.Listener.Accept()
+	l.next = time.Now().Add(l.interval)
+	return conn, err
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// reusePortControl is a net.ListenConfig.Control function that sets
+// SO_REUSEPORT, so several server processes can listen on the same port.
+func reusePortControl(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// setListenBacklog sets the accept queue length of lis. Go always listens
+// with the system maximum, but Linux lets listen(2) be called again on a
+// listening socket to change it. The kernel still caps the value at
+// net.core.somaxconn.
+func setListenBacklog(lis net.Listener, backlog int) error {
+	tcpListener, ok := lis.(*net.TCPListener)
+	if !ok {
+		return fmt.Errorf("LISTEN_BACKLOG: unsupported listener %T", lis)
+	}
+
+	rawConn, err := tcpListener.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var listenErr error
+	err = rawConn.Control(func(fd uintptr) {
+		listenErr = unix.Listen(int(fd), backlog)
+	})
+	if err != nil {
+		return err
+	}
+	if listenErr != nil {
+		return fmt.Errorf("LISTEN_BACKLOG: %v", listenErr)
+	}
+	return nil
+}
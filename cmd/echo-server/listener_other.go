@@ -0,0 +1,21 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// reusePortControl fails on platforms other than Linux, where
+// LISTEN_REUSEPORT is not supported.
+func reusePortControl(network, address string, c syscall.RawConn) error {
+	return fmt.Errorf("LISTEN_REUSEPORT is only supported on Linux")
+}
+
+// setListenBacklog fails on platforms other than Linux, where
+// LISTEN_BACKLOG is not supported.
+func setListenBacklog(lis net.Listener, backlog int) error {
+	return fmt.Errorf("LISTEN_BACKLOG is only supported on Linux")
+}
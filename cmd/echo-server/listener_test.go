@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestThrottledListener verifies the server still serves while accepts are throttled
+func TestThrottledListener(t *testing.T) {
+	t.Setenv("ACCEPT_RATE", "10")
+	if runtime.GOOS == "linux" {
+		t.Setenv("LISTEN_BACKLOG", "16")
+		t.Setenv("LISTEN_REUSEPORT", "true")
+	}
+
+	lis, err := listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	server := &http.Server{Handler: createRouter()}
+	go server.Serve(lis) // nolint:errcheck
+	defer server.Close()
+
+	// A new connection per request, so every request goes through Accept
+	client := &http.Client{
+		Transport: &http.Transport{DisableKeepAlives: true},
+		Timeout:   5 * time.Second,
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get("http://" + lis.Addr().String() + "/throttled")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+	}
+
+	// At 10 connections per second, the third is accepted 200ms after the first
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("expected accepts to be throttled, 3 connections took %s", elapsed)
+	}
+
+	t.Log("TestThrottledListener passed")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"strings"
+)
+
+// logBodyTypes returns the media types whose bodies LOG_HTTP_BODY logs, as
+// configured by the comma-separated LOG_HTTP_BODY_TYPES. Entries may be a
+// type wildcard such as "text/*". It returns nil, logging every body, when
+// unset.
+func logBodyTypes() []string {
+	var types []string
+	for _, t := range strings.Split(os.Getenv("LOG_HTTP_BODY_TYPES"), ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// shouldLogBody reports whether a body with the given Content-Type is logged
+// in full.
+func shouldLogBody(contentType string) bool {
+	types := logBodyTypes()
+	if types == nil {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	major, _, _ := strings.Cut(mediaType, "/")
+
+	for _, t := range types {
+		if t == mediaType || t == major+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// logBody writes the request body to w, or only its size when its
+// Content-Type is not one of LOG_HTTP_BODY_TYPES, so binary bodies do not
+// flood the log.
+func logBody(w io.Writer, contentType string, body []byte) {
+	if shouldLogBody(contentType) {
+		fmt.Fprintf(w, "Body:\n%s\n", body)
+		return
+	}
+
+	if contentType == "" {
+		contentType = "unknown type"
+	}
+	fmt.Fprintf(w, "Body: %d bytes of %s (not logged)\n", len(body), contentType)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestLogBodyTypes verifies LOG_HTTP_BODY_TYPES logs matching bodies in full and only summarizes others
+func TestLogBodyTypes(t *testing.T) {
+	t.Setenv("LOG_HTTP_BODY_TYPES", "application/json, text/*")
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"JSON", "application/json; charset=utf-8", `{"a":1}`, "Body:\n{\"a\":1}\n"},
+		{"Wildcard", "text/csv", "a,b", "Body:\na,b\n"},
+		{"Octet stream", "application/octet-stream", "\x00\x01\x02", "Body: 3 bytes of application/octet-stream (not logged)\n"},
+		{"No content type", "", "raw", "Body: 3 bytes of unknown type (not logged)\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logBody(&buf, tt.contentType, []byte(tt.body))
+
+			if buf.String() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, buf.String())
+			}
+		})
+	}
+
+	t.Run("Unset logs everything", func(t *testing.T) {
+		t.Setenv("LOG_HTTP_BODY_TYPES", "")
+
+		var buf bytes.Buffer
+		logBody(&buf, "application/octet-stream", []byte("binary"))
+
+		if !strings.Contains(buf.String(), "binary") {
+			t.Errorf("expected the body to be logged, got %q", buf.String())
+		}
+	})
+
+	t.Log("TestLogBodyTypes passed")
+}
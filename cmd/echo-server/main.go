@@ -4,18 +4,26 @@ package main
 import (
 	"bytes"
 	"embed"
+	"errors"
 
 	// "encoding/hex"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"path"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"text/template"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"context"
 	echo "http-echo/cmd/echo-server/grpc/generated"
@@ -24,19 +32,41 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
+	"golang.org/x/net/http/httpguts"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 // createRouter creates and configures the HTTP router with all routes
 func createRouter() http.Handler {
 	r := mux.NewRouter()
+	r.Use(allowedHostsMiddleware)
+	r.Use(countClientsMiddleware)
+	r.Use(timeoutMiddleware)
+	r.Use(requestCostMiddleware)
+
+	// Compression settings are validated at startup by validateConfig
+	compression, _ := loadCompressionConfig()
+	r.Use(compressMiddleware(compression))
 
 	// Create pet store and register OpenAPI routes
 	store := openapi.NewPetStore()
+	if ttl, _ := envDuration("IDEMPOTENCY_TTL"); ttl > 0 {
+		store.IdempotencyTTL = ttl
+	}
+	store.MaxPets, _ = envInt("PETSTORE_MAX")
+	store.StrictContentType = envBool("STRICT_CONTENT_TYPE")
+	store.ErrorRate, _ = envFraction("PETSTORE_ERROR_RATE")
+	store.WriteError = func(w http.ResponseWriter, code int, message string) {
+		writeErrorAs(w, code, message, errorFormatCode)
+	}
 	api := r.PathPrefix("/v1").Subrouter()
+	api.Use(store.InjectErrors)
 	api.HandleFunc("/pets", store.ListPets).Methods("GET")
 	api.HandleFunc("/pets", store.CreatePets).Methods("POST")
 	// api.HandleFunc("/pets", store.HandleOptions).Methods("OPTIONS")
@@ -49,31 +79,298 @@ func createRouter() http.Handler {
 	// Add error throwing endpoint
 	r.HandleFunc("/throw", throwErrorHandler).Methods("GET")
 
+	// Add upload benchmarking endpoint
+	r.HandleFunc("/upload", uploadHandler).Methods("POST", "PUT")
+
+	// Add header reflection endpoint
+	r.HandleFunc("/reflect-headers", reflectHeadersHandler).Methods("GET")
+
+	// Add WebSocket push endpoint
+	r.HandleFunc("/inject/{sessionId}", injectHandler).Methods("POST")
+
+	// Add replay of captured echo requests, for admins only
+	r.HandleFunc("/requests/{id}/replay", requireAdmin(replayHandler(r))).Methods("POST")
+
+	// Add per-client request counts, for admins only
+	r.HandleFunc("/stats", requireAdmin(statsHandler)).Methods("GET")
+
+	// Add WebSocket session transcripts, for admins only
+	r.HandleFunc("/ws-transcript/{sessionId}", requireAdmin(wsTranscriptHandler)).Methods("GET")
+
+	// Add encoding utilities
+	r.HandleFunc("/encode", encodeHandler).Methods("GET")
+	r.HandleFunc("/decode", decodeHandler).Methods("GET")
+
+	// Add batches of echo requests
+	r.HandleFunc("/batch", batchHandler(r)).Methods("POST")
+
+	// Add simulated slow database query
+	r.HandleFunc("/query", queryHandler).Methods("GET")
+
+	// Add runtime configuration, for admins only
+	r.HandleFunc("/admin/config", requireAdmin(adminConfigHandler)).Methods("GET", "POST")
+
+	// Add JSON shim over the gRPC Echo call
+	grpcWarmup, _ := envDuration("GRPC_WARMUP") // Validated at startup by validateConfig
+	r.HandleFunc("/grpc-echo", grpcEchoHandler(&grpcEchoServer{
+		readyAt: time.Now().Add(grpcWarmup),
+	})).Methods("POST")
+
+	// Add profiling endpoints, off by default
+	if envBool("ENABLE_PPROF") {
+		r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		r.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		r.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		r.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
+	}
+
+	// Add deliberate panics to test recovery, off by default
+	if envBool("ENABLE_PANIC_ENDPOINT") {
+		r.HandleFunc("/panic", panicHandler)
+	}
+
+	// httpbin-compatible endpoints
+	r.HandleFunc("/headers", headersHandler).Methods("GET")
+	r.HandleFunc("/get", getHandler).Methods("GET")
+	r.HandleFunc("/post", postHandler).Methods("POST")
+	r.HandleFunc("/robots.txt", robotsHandler).Methods("GET")
+	r.HandleFunc("/deny", denyHandler).Methods("GET")
+	r.HandleFunc("/base64/encode", base64EncodeHandler).Methods("GET")
+	r.HandleFunc("/base64/{value}", base64DecodeHandler).Methods("GET")
+	r.HandleFunc("/stream/{n}", streamHandler).Methods("GET")
+	r.HandleFunc("/bytes/{n}", bytesHandler).Methods("GET")
+	r.HandleFunc("/links/{n}", linksRedirectHandler).Methods("GET")
+	r.HandleFunc("/links/{n}/{offset}", linksHandler).Methods("GET")
+	r.HandleFunc("/cookies", cookiesHandler).Methods("GET")
+	r.HandleFunc("/cookies/set", setCookiesHandler).Methods("GET")
+	r.HandleFunc("/cookies/delete", deleteCookiesHandler).Methods("GET")
+
 	// Default handler for echo server functionality
 	r.PathPrefix("/").HandlerFunc(handler)
 
+	// Trailing-slash handling must happen before routing, and is validated at
+	// startup by validateConfig
+	slashMode, _ := trailingSlashMode()
+
 	return h2c.NewHandler(
-		r,
+		recoverMiddleware(countConnRequests(trailingSlashHandler(slashMode, r))),
 		&http2.Server{},
 	)
 }
 
 // startGRPCServer starts the gRPC server on the specified port
 func startGRPCServer(grpcPort string) error {
+	s, err := newGRPCServer()
+	if err != nil {
+		return err
+	}
+
 	lis, err := net.Listen("tcp", ":"+grpcPort)
 	if err != nil {
 		return fmt.Errorf("failed to listen: %v", err)
 	}
-	s := grpc.NewServer()
-	echo.RegisterEchoServer(s, &grpcEchoServer{})
-	reflection.Register(s)
 	if err := s.Serve(lis); err != nil {
 		return fmt.Errorf("failed to serve gRPC: %v", err)
 	}
 	return nil
 }
 
+// newGRPCServer returns a gRPC server with the echo service registered,
+// configured from the environment.
+func newGRPCServer() (*grpc.Server, error) {
+	warmup, err := envDuration("GRPC_WARMUP")
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(recoveryUnaryInterceptor, compressionUnaryInterceptor, statusUnaryInterceptor, cpuLoadUnaryInterceptor),
+		grpc.ChainStreamInterceptor(recoveryStreamInterceptor, compressionStreamInterceptor, statusStreamInterceptor, cpuLoadStreamInterceptor),
+	}
+
+	// Streams beyond the limit wait until others finish, per HTTP/2
+	// SETTINGS_MAX_CONCURRENT_STREAMS. gRPC does not limit them by default.
+	maxStreams, err := envInt("GRPC_MAX_CONCURRENT_STREAMS")
+	if err != nil {
+		return nil, err
+	}
+	if maxStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(uint32(maxStreams)))
+	}
+
+	s := grpc.NewServer(opts...)
+	echo.RegisterEchoServer(s, &grpcEchoServer{
+		readyAt: time.Now().Add(warmup),
+	})
+	reflection.Register(s)
+	return s, nil
+}
+
+// validateConfig checks the environment configuration so that invalid values
+// are reported at startup rather than on the first request.
+func validateConfig() error {
+	if _, err := loadCompressionConfig(); err != nil {
+		return err
+	}
+	if _, err := chaosErrorRate(); err != nil {
+		return err
+	}
+	if _, err := resetRate(); err != nil {
+		return err
+	}
+	if _, err := errorFormat(); err != nil {
+		return err
+	}
+	if _, err := envDuration("GRPC_WARMUP"); err != nil {
+		return err
+	}
+	if _, err := envInt("GRPC_MAX_CONCURRENT_STREAMS"); err != nil {
+		return err
+	}
+	if _, err := envInt("MAX_URL_LENGTH"); err != nil {
+		return err
+	}
+	if _, err := envInt("MAX_HEADER_VALUE_LENGTH"); err != nil {
+		return err
+	}
+	if _, err := envInt("MAX_QUERY_PARAMS"); err != nil {
+		return err
+	}
+	if _, err := envInt("MAX_BODY_BYTES"); err != nil {
+		return err
+	}
+	if _, err := envInt("MAX_ECHO_BODY_BYTES"); err != nil {
+		return err
+	}
+	if _, err := envInt("CAPTURE_REQUESTS"); err != nil {
+		return err
+	}
+	if _, err := envInt("CLIENT_STATS"); err != nil {
+		return err
+	}
+	if _, err := envInt("REQUEST_COST_BUDGET"); err != nil {
+		return err
+	}
+	if _, err := envDuration("REQUEST_COST_WINDOW"); err != nil {
+		return err
+	}
+	if _, err := envDuration("REQUEST_COST_DELAY"); err != nil {
+		return err
+	}
+	if _, err := webhookURL(); err != nil {
+		return err
+	}
+	if _, err := envInt("MAX_REQUESTS_PER_CONN"); err != nil {
+		return err
+	}
+	if _, err := maxAlloc(); err != nil {
+		return err
+	}
+	if _, err := respondWithHeader(); err != nil {
+		return err
+	}
+	if _, err := envDuration("REFLECT_COOKIES_MAX_AGE"); err != nil {
+		return err
+	}
+	if _, err := envDuration("SLOW_HEADERS"); err != nil {
+		return err
+	}
+	if _, err := rawHeaderCase(); err != nil {
+		return err
+	}
+	if _, err := grpcCPULoad(); err != nil {
+		return err
+	}
+	if _, err := grpcCompression(); err != nil {
+		return err
+	}
+	if _, err := pathRewriteRule(); err != nil {
+		return err
+	}
+	if _, _, err := trustedProxies(); err != nil {
+		return err
+	}
+	if _, err := allowedHosts(); err != nil {
+		return err
+	}
+	if _, err := noContentPatterns(); err != nil {
+		return err
+	}
+	if _, err := methodStatuses(); err != nil {
+		return err
+	}
+	if _, err := allowedMethods(); err != nil {
+		return err
+	}
+	if _, err := envInt("PETSTORE_MAX"); err != nil {
+		return err
+	}
+	if _, err := envFraction("PETSTORE_ERROR_RATE"); err != nil {
+		return err
+	}
+	if _, err := envDuration("IDEMPOTENCY_TTL"); err != nil {
+		return err
+	}
+	if _, err := trailingSlashMode(); err != nil {
+		return err
+	}
+	if _, err := envInt("LISTEN_BACKLOG"); err != nil {
+		return err
+	}
+	if _, err := envInt("ACCEPT_RATE"); err != nil {
+		return err
+	}
+	if _, err := wsWriteTimeout(); err != nil {
+		return err
+	}
+	if _, err := websocketScheme(); err != nil {
+		return err
+	}
+	if _, err := wsGreetingTemplate(); err != nil {
+		return err
+	}
+	if _, err := wsEchoFilter(); err != nil {
+		return err
+	}
+	if _, err := loadDelayConfig(); err != nil {
+		return err
+	}
+	if _, err := envDuration("REQUEST_TIMEOUT"); err != nil {
+		return err
+	}
+	if _, err := requestTimeoutStatus(); err != nil {
+		return err
+	}
+	if _, err := envInt("SSE_MAX_CONNECTIONS"); err != nil {
+		return err
+	}
+	if _, err := envInt("SSE_MAX_EVENTS"); err != nil {
+		return err
+	}
+	if _, err := envDuration("SSE_MAX_DURATION"); err != nil {
+		return err
+	}
+	if _, err := envDuration("SSE_RETRY"); err != nil {
+		return err
+	}
+	if _, err := tlsMinVersion(); err != nil {
+		return err
+	}
+	if _, err := tlsCipherSuites(); err != nil {
+		return err
+	}
+	if envBool("ENABLE_HTTP3") && (os.Getenv("TLS_CERT_FILE") == "" || os.Getenv("TLS_KEY_FILE") == "") {
+		return fmt.Errorf("ENABLE_HTTP3 requires TLS_CERT_FILE and TLS_KEY_FILE, as QUIC always uses TLS")
+	}
+	return nil
+}
+
 func main() {
+	if err := validateConfig(); err != nil {
+		panic(err)
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -84,9 +381,32 @@ func main() {
 		grpcPort = "9090"
 	}
 
+	tlsConfig, err := newTLSConfig()
+	if err != nil {
+		panic(err)
+	}
+
+	tlsPort := os.Getenv("TLS_PORT")
+	if tlsPort == "" {
+		tlsPort = "8443"
+	}
+
+	if envBool("SELF_TEST") {
+		if err := runSelfTest(port, grpcPort); err != nil {
+			fmt.Printf("Self-test failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	fmt.Printf("Version: 0.0.1\n")
 
 	fmt.Printf("Echo HTTP server listening on port %s.\n", port)
+	if tlsConfig != nil {
+		fmt.Printf("Echo HTTPS server listening on port %s.\n", tlsPort)
+		if envBool("ENABLE_HTTP3") {
+			fmt.Printf("Echo HTTP/3 server listening on UDP port %s.\n", tlsPort)
+		}
+	}
 	fmt.Printf("Echo gRPC server listening on port %s.\n", grpcPort)
 
 	// Start gRPC server in goroutine
@@ -96,8 +416,49 @@ func main() {
 		}
 	}()
 
+	router := createRouter()
+
+	// Start HTTPS server in goroutine when a certificate is configured
+	if tlsConfig != nil {
+		tlsHandler := http.Handler(router)
+
+		// Start HTTP/3 server on the same port number over UDP
+		if envBool("ENABLE_HTTP3") {
+			h3 := newHTTP3Server(":"+tlsPort, router, tlsConfig)
+			tlsHandler = altSvcMiddleware(h3, router)
+
+			go func() {
+				if err := h3.ListenAndServe(); err != nil {
+					panic(err)
+				}
+			}()
+		}
+
+		tlsListener, err := listen(":" + tlsPort)
+		if err != nil {
+			panic(err)
+		}
+
+		go func() {
+			server := newTLSServer(tlsHandler, tlsConfig)
+			if err := server.ServeTLS(tlsListener, "", ""); err != nil {
+				panic(err)
+			}
+		}()
+	}
+
 	// Start HTTP server
-	err := http.ListenAndServe(":"+port, createRouter())
+	lis, err := listen(":" + port)
+	if err != nil {
+		panic(err)
+	}
+
+	server := &http.Server{
+		Handler:     router,
+		ConnContext: connContext,
+	}
+
+	err = server.Serve(lis)
 	if err != nil {
 		panic(err)
 	}
@@ -106,17 +467,62 @@ func main() {
 // grpcEchoServer implements echo.EchoServer
 type grpcEchoServer struct {
 	echo.UnimplementedEchoServer
+
+	// readyAt is the time after which calls are served. Until then, calls
+	// fail with Unavailable to simulate a backend that is still warming up.
+	readyAt time.Time
 }
 
 func (s *grpcEchoServer) Echo(ctx context.Context, req *echo.EchoRequest) (*echo.EchoResponse, error) {
-	fmt.Printf("gRPC Echo called: %s\n", req.GetMessage())
+	if err := s.checkReady("Echo"); err != nil {
+		return nil, err
+	}
+
+	remaining := grpcDeadlineRemaining(ctx)
+	grpc.SetHeader(ctx, metadata.Pairs(grpcDeadlineHeader, remaining)) // nolint:errcheck
+
+	fmt.Printf("gRPC Echo called: %s (deadline: %s)\n", req.GetMessage(), remaining)
 	return &echo.EchoResponse{Message: req.GetMessage()}, nil
 }
 
-// healthCheck provides a simple health check endpoint
+// checkReady fails calls to method with Unavailable until the warmup period
+// configured by GRPC_WARMUP has elapsed.
+func (s *grpcEchoServer) checkReady(method string) error {
+	if remaining := time.Until(s.readyAt); remaining > 0 {
+		fmt.Printf("gRPC %s rejected: warming up for another %s\n", method, remaining.Round(time.Millisecond))
+		return status.Errorf(codes.Unavailable, "server is warming up, retry in %s", remaining.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// grpcDeadlineHeader is the response metadata key reporting the time left
+// until the call's deadline when the server received it.
+const grpcDeadlineHeader = "x-echo-deadline-remaining"
+
+// grpcDeadlineRemaining returns the time left until the deadline of ctx, or
+// "none" when the client did not set one.
+func grpcDeadlineRemaining(ctx context.Context) string {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return "none"
+	}
+	return time.Until(deadline).Round(time.Millisecond).String()
+}
+
+// healthCheck provides a simple health check endpoint. It answers with JSON
+// unless the client prefers text/plain, in which case it answers "healthy".
 func healthCheck(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Add("Vary", "Accept")
+
+	if negotiateMediaType(r.Header.Get("Accept"), []string{"application/json", "text/plain"}) == "text/plain" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "healthy")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, `{"status":"healthy","timestamp":"%s"}`, time.Now().Format(time.RFC3339))
 }
@@ -127,15 +533,36 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// wsCompressionEnabled reports whether permessage-deflate should be
+// negotiated with WebSocket clients that offer it. It is enabled unless
+// WS_COMPRESSION is set to "false".
+func wsCompressionEnabled() bool {
+	return !strings.EqualFold(os.Getenv("WS_COMPRESSION"), "false")
+}
+
 func handler(wr http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	req = req.WithContext(context.WithValue(req.Context(), receivedAtKey{}, start))
 	defer req.Body.Close()
 
+	if !checkRequestLimits(wr, req) {
+		return
+	}
+
+	if rejectInMaintenance(wr) {
+		return
+	}
+
 	if os.Getenv("LOG_HTTP_BODY") != "" || os.Getenv("LOG_HTTP_HEADERS") != "" {
 		fmt.Printf("--------  %s | %s %s\n", req.RemoteAddr, req.Method, req.URL)
 	} else {
 		fmt.Printf("%s | %s %s\n", req.RemoteAddr, req.Method, req.URL)
 	}
 
+	if envBool("LOG_PROTOCOL") {
+		fmt.Printf("%s | protocol | %s\n", req.RemoteAddr, negotiatedProtocol(req))
+	}
+
 	if os.Getenv("LOG_HTTP_HEADERS") != "" {
 		fmt.Printf("Headers\n")
 		printHeaders(os.Stdout, req.Header)
@@ -146,7 +573,7 @@ func handler(wr http.ResponseWriter, req *http.Request) {
 		buf.ReadFrom(req.Body) // nolint:errcheck
 
 		if buf.Len() != 0 {
-			fmt.Printf("Body:\n%s\n", buf.String())
+			logBody(os.Stdout, req.Header.Get("Content-Type"), buf.Bytes())
 		}
 
 		// Replace original body with buffered version so it's still sent to the
@@ -157,16 +584,11 @@ func handler(wr http.ResponseWriter, req *http.Request) {
 		)
 	}
 
-	sendServerHostnameString := os.Getenv("SEND_SERVER_HOSTNAME")
+	sendServerHostname := defaultSendServerHostname()
 	if v := req.Header.Get("X-Send-Server-Hostname"); v != "" {
-		sendServerHostnameString = v
+		sendServerHostname = !strings.EqualFold(v, "false")
 	}
 
-	sendServerHostname := !strings.EqualFold(
-		sendServerHostnameString,
-		"false",
-	)
-
 	for _, line := range os.Environ() {
 		parts := strings.SplitN(line, "=", 2)
 		key, value := parts[0], parts[1]
@@ -179,6 +601,35 @@ func handler(wr http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	if !websocket.IsWebSocketUpgrade(req) {
+		captureRequest(wr, req)
+		sendWebhookEvent(req)
+	}
+
+	held, ok := allocateRequestMemory(wr, req)
+	if !ok {
+		return
+	}
+	defer runtime.KeepAlive(held)
+
+	if !delayRequest(req) {
+		return
+	}
+
+	if injectChaos(wr, req) {
+		return
+	}
+
+	if injectReset(wr, req) {
+		return
+	}
+
+	req = rewritePath(req)
+
+	if envBool("SEND_UPGRADE_INFO") && req.Header.Get("Upgrade") != "" {
+		fmt.Printf("%s | upgrade | %s\n", req.RemoteAddr, upgradeDecision(req))
+	}
+
 	if websocket.IsWebSocketUpgrade(req) {
 		serveWebSocket(wr, req, sendServerHostname)
 	} else if path.Base(req.URL.Path) == ".ws" {
@@ -186,12 +637,45 @@ func handler(wr http.ResponseWriter, req *http.Request) {
 	} else if path.Base(req.URL.Path) == ".sse" {
 		serveSSE(wr, req, sendServerHostname)
 	} else {
-		serveHTTP(wr, req, sendServerHostname)
+		serveHTTP(wr, req, sendServerHostname, start)
 	}
 }
 
 func serveWebSocket(wr http.ResponseWriter, req *http.Request, sendServerHostname bool) {
-	connection, err := upgrader.Upgrade(wr, req, nil)
+	textDelay, binaryDelay, err := wsFrameDelays(req)
+	if err != nil {
+		writeError(wr, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tickInterval, err := wsTickInterval(req)
+	if err != nil {
+		writeError(wr, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	maxMessages, err := wsMaxMessages(req)
+	if err != nil {
+		writeError(wr, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ackMode, err := wsAckMode(req)
+	if err != nil {
+		writeError(wr, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	wsUpgrader := upgrader
+	wsUpgrader.EnableCompression = wsCompressionEnabled()
+
+	var responseHeader http.Header
+	nonce := echoNonce(req)
+	if nonce != "" {
+		responseHeader = http.Header{echoNonceHeader: {nonce}}
+	}
+
+	connection, err := wsUpgrader.Upgrade(wr, req, responseHeader)
 	if err != nil {
 		fmt.Printf("%s | %s\n", req.RemoteAddr, err)
 		return
@@ -200,26 +684,52 @@ func serveWebSocket(wr http.ResponseWriter, req *http.Request, sendServerHostnam
 	defer connection.Close()
 	fmt.Printf("%s | upgraded to websocket\n", req.RemoteAddr)
 
-	var message []byte
+	message, sendGreeting := wsGreeting(sendServerHostname, nonce)
 
-	if sendServerHostname {
-		host, err := os.Hostname()
-		if err == nil {
-			message = []byte(fmt.Sprintf("Request served by %s", host))
-		} else {
-			message = []byte(fmt.Sprintf("Server hostname unknown: %s", err.Error()))
-		}
+	// Validated at startup by validateConfig
+	writeTimeout, _ := wsWriteTimeout()
+	writer := &wsWriter{conn: connection, timeout: writeTimeout}
+
+	// Messages posted to /inject/{sessionId} are pushed to this connection
+	session := req.URL.Query().Get("session")
+	if session != "" {
+		defer wsSessions.register(session, writer)()
 	}
 
-	err = connection.WriteMessage(websocket.TextMessage, message)
+	// Messages of the session are kept for /ws-transcript/{sessionId}
+	recordTranscript := session != "" && envBool("WS_TRANSCRIPTS")
+	if recordTranscript {
+		writer.session = session
+	}
+
+	if sendGreeting {
+		err = writer.WriteMessage(websocket.TextMessage, message)
+	}
+
+	// Push server-generated messages between echoes until the connection ends
+	if err == nil && tickInterval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go sendWebSocketTicks(writer, tickInterval, done)
+	}
+
+	// Validated at startup by validateConfig
+	filter, _ := wsEchoFilter()
+
 	if err == nil {
 		var messageType int
+		var received, echoed int
 
 		for {
 			messageType, message, err = connection.ReadMessage()
 			if err != nil {
 				break
 			}
+			received++
+
+			if recordTranscript {
+				wsTranscripts.record(session, "received", messageType, message)
+			}
 
 			if messageType == websocket.TextMessage {
 				fmt.Printf("%s | txt | %s\n", req.RemoteAddr, message)
@@ -227,21 +737,250 @@ func serveWebSocket(wr http.ResponseWriter, req *http.Request, sendServerHostnam
 				fmt.Printf("%s | bin | %d byte(s)\n", req.RemoteAddr, len(message))
 			}
 
-			err = connection.WriteMessage(messageType, message)
-			if err != nil {
+			// Acknowledge every message received, whether or not it is echoed
+			if ackMode != wsAckOff {
+				if err = writeWSAck(writer, received, len(message)); err != nil {
+					break
+				}
+			}
+
+			if filter != nil && !filter.Match(message) {
+				fmt.Printf("%s | ws filter | dropped message not matching %s\n", req.RemoteAddr, filter)
+				if envBool("WS_ECHO_FILTER_REPLY") {
+					err = writer.WriteMessage(websocket.TextMessage, []byte("Message dropped: it does not match WS_ECHO_FILTER"))
+					if err != nil {
+						break
+					}
+				}
+				continue
+			}
+
+			if ackMode != wsAckOnly {
+				if messageType == websocket.TextMessage {
+					time.Sleep(textDelay)
+				} else {
+					time.Sleep(binaryDelay)
+				}
+
+				err = writer.WriteMessage(messageType, message)
+				if err != nil {
+					break
+				}
+			}
+
+			// With ack=only, the acknowledgments count as the replies
+			if echoed++; maxMessages > 0 && echoed >= maxMessages {
+				fmt.Printf("%s | ws closing | replied to %d message(s)\n", req.RemoteAddr, echoed)
+				reason := fmt.Sprintf("max-messages %d reached", maxMessages)
+				err = writer.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason))
 				break
 			}
 		}
 	}
 
 	if err != nil {
-		fmt.Printf("%s | %s\n", req.RemoteAddr, err)
+		logWebSocketClose(req, err)
+	}
+}
+
+// wsFrameDelays returns how long to wait before echoing text and binary
+// frames, from the "text-delay" and "bin-delay" query parameters (e.g.
+// "250ms"). Both default to zero and are capped at MAX_DELAY.
+func wsFrameDelays(req *http.Request) (time.Duration, time.Duration, error) {
+	cfg, _ := loadDelayConfig()
+
+	var delays [2]time.Duration
+	for i, name := range []string{"text-delay", "bin-delay"} {
+		v := req.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return 0, 0, fmt.Errorf("Invalid %s %q", name, v)
+		}
+		delays[i] = min(d, cfg.max)
+	}
+
+	return delays[0], delays[1], nil
+}
+
+// wsMaxMessages returns the number of messages echoed before the server
+// closes the connection, from the "max-messages" query parameter. It is
+// zero, meaning no limit, when the parameter is absent.
+func wsMaxMessages(req *http.Request) (int, error) {
+	v := req.URL.Query().Get("max-messages")
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("Invalid max-messages %q, must be a positive integer", v)
+	}
+	return n, nil
+}
+
+// minWSTickInterval is the shortest interval accepted for periodic
+// WebSocket messages.
+const minWSTickInterval = 10 * time.Millisecond
+
+// wsTickInterval returns the interval of server-initiated WebSocket
+// messages, from the "tick" query parameter (e.g. "1s"). It is zero, meaning
+// no periodic messages, when the parameter is absent.
+func wsTickInterval(req *http.Request) (time.Duration, error) {
+	v := req.URL.Query().Get("tick")
+	if v == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d < minWSTickInterval {
+		return 0, fmt.Errorf("Invalid tick %q, must be a duration of at least %s", v, minWSTickInterval)
+	}
+	return d, nil
+}
+
+// sendWebSocketTicks sends a counter and timestamp text message every
+// interval until done is closed or a write fails.
+func sendWebSocketTicks(writer *wsWriter, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for n := 1; ; n++ {
+		select {
+		case <-done:
+			return
+		case t := <-ticker.C:
+			message := fmt.Sprintf("Tick %d at %s", n, t.UTC().Format(time.RFC3339Nano))
+			if err := writer.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// defaultWSWriteTimeout bounds WebSocket writes when WS_WRITE_TIMEOUT is
+// not set.
+const defaultWSWriteTimeout = 10 * time.Second
+
+// wsWriteTimeout returns how long a single WebSocket write may block, as
+// configured by WS_WRITE_TIMEOUT.
+func wsWriteTimeout() (time.Duration, error) {
+	if os.Getenv("WS_WRITE_TIMEOUT") == "" {
+		return defaultWSWriteTimeout, nil
+	}
+	return envDuration("WS_WRITE_TIMEOUT")
+}
+
+// wsWriter writes messages to a WebSocket connection. Writes are serialized,
+// as a connection supports only one concurrent writer, and each has a
+// deadline, so a client that stops reading cannot block the writer forever.
+// A zero timeout disables the deadline.
+type wsWriter struct {
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	timeout time.Duration
+
+	// session is the id under which written messages are recorded in
+	// wsTranscripts, or empty when they are not recorded.
+	session string
+}
+
+// WriteMessage writes a single message to the connection.
+func (w *wsWriter) WriteMessage(messageType int, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timeout > 0 {
+		w.conn.SetWriteDeadline(time.Now().Add(w.timeout)) // nolint:errcheck
+	}
+	if err := w.conn.WriteMessage(messageType, data); err != nil {
+		return err
+	}
+
+	if w.session != "" {
+		wsTranscripts.record(w.session, "sent", messageType, data)
+	}
+	return nil
+}
+
+// Counters of WebSocket connections that ended cleanly and abnormally.
+var (
+	wsClosedNormally     atomic.Int64
+	wsClosedUnexpectedly atomic.Int64
+)
+
+// logWebSocketClose logs why a WebSocket connection ended, distinguishing
+// clean closures from abnormal ones.
+func logWebSocketClose(req *http.Request, err error) {
+	var netErr net.Error
+
+	switch {
+	case websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway):
+		wsClosedNormally.Add(1)
+		fmt.Printf("%s | ws closed | %s\n", req.RemoteAddr, err)
+	case websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway):
+		wsClosedUnexpectedly.Add(1)
+		fmt.Printf("%s | ws closed unexpectedly | %s\n", req.RemoteAddr, err)
+	case errors.As(err, &netErr) && netErr.Timeout():
+		wsClosedUnexpectedly.Add(1)
+		fmt.Printf("%s | ws write timeout | %s\n", req.RemoteAddr, err)
+	default:
+		wsClosedUnexpectedly.Add(1)
+		fmt.Printf("%s | ws error | %s\n", req.RemoteAddr, err)
 	}
 }
 
 //go:embed "html"
 var files embed.FS
 
+// websocketScheme returns the WebSocket URL scheme the frontend connects
+// with, as configured by WEBSOCKET_SCHEME: "ws", "wss", or "" to derive it
+// from the request.
+func websocketScheme() (string, error) {
+	switch v := strings.ToLower(os.Getenv("WEBSOCKET_SCHEME")); v {
+	case "", "ws", "wss":
+		return v, nil
+	default:
+		return "", fmt.Errorf("WEBSOCKET_SCHEME: %q is not ws or wss", v)
+	}
+}
+
+// frontendWebSocketOrigin returns the scheme and host the frontend served for
+// req connects its WebSocket to. WEBSOCKET_SCHEME and WEBSOCKET_HOST take
+// precedence; otherwise the scheme is "wss" when the request arrived over TLS
+// and the host is the request's, both overridden by the X-Forwarded-Proto and
+// X-Forwarded-Host headers of a trusted proxy.
+func frontendWebSocketOrigin(req *http.Request) (scheme, host string) {
+	scheme = "ws"
+	if req.TLS != nil {
+		scheme = "wss"
+	}
+	host = req.Host
+
+	if isTrustedProxy(req) {
+		switch strings.ToLower(firstHeaderValue(req.Header, "X-Forwarded-Proto")) {
+		case "https", "wss":
+			scheme = "wss"
+		case "http", "ws":
+			scheme = "ws"
+		}
+		if h := firstHeaderValue(req.Header, "X-Forwarded-Host"); h != "" && httpguts.ValidHostHeader(h) {
+			host = h
+		}
+	}
+
+	if v, _ := websocketScheme(); v != "" { // Validated at startup by validateConfig
+		scheme = v
+	}
+	if v := os.Getenv("WEBSOCKET_HOST"); v != "" {
+		host = v
+	}
+	return scheme, host
+}
+
 func serveFrontend(wr http.ResponseWriter, req *http.Request) {
 	const templateName = "html/frontend.tmpl.html"
 	tmpl, err := template.ParseFS(files, templateName)
@@ -249,9 +988,14 @@ func serveFrontend(wr http.ResponseWriter, req *http.Request) {
 		http.Error(wr, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	scheme, host := frontendWebSocketOrigin(req)
 	templateData := struct {
-		Path string
+		Scheme string
+		Host   string
+		Path   string
 	}{
+		Scheme: scheme,
+		Host:   host,
 		Path: path.Join(
 			os.Getenv("WEBSOCKET_ROOT"),
 			path.Dir(req.URL.Path),
@@ -266,20 +1010,290 @@ func serveFrontend(wr http.ResponseWriter, req *http.Request) {
 	wr.WriteHeader(200)
 }
 
-func serveHTTP(wr http.ResponseWriter, req *http.Request, sendServerHostname bool) {
-	wr.Header().Add("Content-Type", "text/plain")
-	wr.WriteHeader(200)
+// isPlausibleMediaType reports whether v is a syntactically valid
+// "type/subtype" media type, optionally followed by parameters.
+func isPlausibleMediaType(v string) bool {
+	mediaType, _, err := mime.ParseMediaType(v)
+	if err != nil {
+		return false
+	}
+
+	typ, subtype, ok := strings.Cut(mediaType, "/")
+	return ok && typ != "" && subtype != "" && !strings.Contains(subtype, "/")
+}
+
+// sanitizeFilename reduces a client-supplied file name to a base name of
+// printable ASCII characters that is safe to put in a quoted
+// Content-Disposition parameter. Quotes, backslashes and control characters,
+// which could break out of the header, are dropped.
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+
+	if name == "." || name == "/" || name == ".." {
+		return ""
+	}
+	return strings.TrimSpace(name)
+}
+
+// echoNonceHeader is the request header carrying a client-supplied nonce
+// that is echoed back verbatim, letting clients correlate concurrent
+// requests without parsing the full echo.
+const echoNonceHeader = "X-Echo-Nonce"
+
+// echoNonce returns the client-supplied nonce, or an empty string.
+func echoNonce(req *http.Request) string {
+	return req.Header.Get(echoNonceHeader)
+}
+
+// noContentPatterns returns the path patterns configured in
+// NO_CONTENT_PATHS, a comma-separated list of path.Match patterns.
+func noContentPatterns() ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(os.Getenv("NO_CONTENT_PATHS"), ",") {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("NO_CONTENT_PATHS: invalid pattern %q: %v", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// isNoContentRequest reports whether req is a body-less GET to a path
+// matching NO_CONTENT_PATHS, which is answered with 204 No Content instead of
+// an echo.
+func isNoContentRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet || req.ContentLength != 0 {
+		return false
+	}
+
+	patterns, _ := noContentPatterns()
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, req.URL.Path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultAllowedMethods is the Allow header sent with echoes of OPTIONS
+// requests when ALLOWED_METHODS is unset.
+var defaultAllowedMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// allowedMethods returns the methods listed in the Allow header of echoes of
+// OPTIONS requests, as configured by the comma-separated ALLOWED_METHODS.
+func allowedMethods() ([]string, error) {
+	v := os.Getenv("ALLOWED_METHODS")
+	if v == "" {
+		return defaultAllowedMethods, nil
+	}
+
+	var methods []string
+	for _, method := range strings.Split(v, ",") {
+		method = strings.TrimSpace(method)
+		if !isToken(method) {
+			return nil, fmt.Errorf("ALLOWED_METHODS: %q is not a valid method name", method)
+		}
+		methods = append(methods, method)
+	}
+	return methods, nil
+}
+
+// methodStatuses returns the echo status codes configured per HTTP method by
+// ECHO_<METHOD>_STATUS variables (e.g. ECHO_POST_STATUS=201), keyed by
+// upper-case method.
+func methodStatuses() (map[string]int, error) {
+	statuses := map[string]int{}
+
+	for _, line := range os.Environ() {
+		key, value, _ := strings.Cut(line, "=")
+
+		method, ok := strings.CutPrefix(key, "ECHO_")
+		if !ok {
+			continue
+		}
+		if method, ok = strings.CutSuffix(method, "_STATUS"); !ok || method == "" {
+			continue
+		}
+
+		code, err := strconv.Atoi(value)
+		if err != nil || code < 200 || code > 599 {
+			return nil, fmt.Errorf("%s: %q is not a valid status code (200-599)", key, value)
+		}
+		statuses[strings.ToUpper(method)] = code
+	}
+
+	return statuses, nil
+}
+
+func serveHTTP(wr http.ResponseWriter, req *http.Request, sendServerHostname bool, start time.Time) {
+	if isNoContentRequest(req) {
+		wr.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if expression, ok := jqExpression(req); ok {
+		serveJQ(wr, req, expression)
+		return
+	}
+
+	contentType := "text/plain"
+	if v := req.URL.Query().Get("content-type"); v != "" {
+		if !isPlausibleMediaType(v) {
+			writeError(wr, http.StatusBadRequest, fmt.Sprintf("Invalid content-type %q", v))
+			return
+		}
+		contentType = v
+	}
+
+	format, err := echoFormat(req)
+	if err != nil {
+		writeError(wr, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Method == http.MethodOptions {
+		methods, _ := allowedMethods() // Validated at startup by validateConfig
+		wr.Header().Set("Allow", strings.Join(methods, ", "))
+	}
+
+	if v, ok := req.URL.Query()["download"]; ok {
+		filename := sanitizeFilename(v[0])
+		if filename == "" {
+			writeError(wr, http.StatusBadRequest, fmt.Sprintf("Invalid download file name %q", v[0]))
+			return
+		}
+		wr.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	}
+
+	if v := req.Header.Get(serverTimingRequestHeader); v != "" {
+		timing, err := parseServerTiming(v)
+		if err != nil {
+			writeError(wr, http.StatusBadRequest, err.Error())
+			return
+		}
+		wr.Header().Set("Server-Timing", timing)
+	}
+
+	nonce := echoNonce(req)
+	if nonce != "" {
+		wr.Header().Set(echoNonceHeader, nonce)
+	}
+
+	reflectCookies(wr, req)
+
+	// Overrides are validated at startup by validateConfig
+	code := http.StatusOK
+	statuses, _ := methodStatuses()
+	if c, ok := statuses[strings.ToUpper(req.Method)]; ok {
+		code = c
+	}
+
+	if serveRespondWith(wr, req, code, contentType) {
+		return
+	}
+
+	// Identical GET and HEAD requests get the same ETag, so clients can
+	// revalidate cached echoes
+	if envBool("ECHO_ETAG") && code == http.StatusOK && (req.Method == http.MethodGet || req.Method == http.MethodHead) {
+		etag := echoETag(req)
+		wr.Header().Set("ETag", etag)
+		if v := req.Header.Get("If-None-Match"); v != "" && etagMatches(v, etag) {
+			wr.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	if format != "" {
+		serveStructuredEcho(wr, req, code, format, nonce, sendServerHostname)
+		return
+	}
+
+	wr.Header().Add("Content-Type", contentType)
+
+	// Go's server writes headers canonically and all at once, so slow headers
+	// and raw header casing are written by hand on HTTP/1.x
+	delay, _ := envDuration("SLOW_HEADERS")
+	headerCase, _ := rawHeaderCase()
+	if delay > 0 || headerCase != nil {
+		var body bytes.Buffer
+		writeEchoBody(&body, req, nonce, sendServerHostname, start)
+		if !serveManualResponse(wr, req, code, body.Bytes(), delay, headerCase) {
+			wr.WriteHeader(code)
+			wr.Write(body.Bytes()) // nolint:errcheck
+		}
+		return
+	}
+
+	wr.WriteHeader(code)
+
+	// Send the headers and the body as separate flushes rather than letting
+	// the server buffer the response
+	flush := envBool("FLUSH_IMMEDIATELY")
+	rc := http.NewResponseController(wr)
+	if flush {
+		rc.Flush() // nolint:errcheck
+	}
+
+	writeEchoBody(wr, req, nonce, sendServerHostname, start)
+
+	if flush {
+		rc.Flush() // nolint:errcheck
+	}
+}
+
+// writeEchoBody writes the body of an HTTP echo response to w.
+func writeEchoBody(w io.Writer, req *http.Request, nonce string, sendServerHostname bool, start time.Time) {
+	body := &countingWriter{w: w}
+
+	if nonce != "" {
+		fmt.Fprintf(body, "Nonce: %s\n\n", nonce)
+	}
 
 	if sendServerHostname {
 		host, err := os.Hostname()
 		if err == nil {
-			fmt.Fprintf(wr, "Request served by %s\n\n", host)
+			fmt.Fprintf(body, "Request served by %s\n\n", host)
 		} else {
-			fmt.Fprintf(wr, "Server hostname unknown: %s\n\n", err.Error())
+			fmt.Fprintf(body, "Server hostname unknown: %s\n\n", err.Error())
 		}
 	}
 
-	writeRequest(wr, req)
+	writeRequest(body, req)
+
+	if envBool("SEND_FOOTER") {
+		writeFooter(w, body.n, start)
+	}
+}
+
+// activeSSEStreams is the number of SSE streams currently being served.
+var activeSSEStreams atomic.Int64
+
+// acquireSSEStream counts a new SSE stream as active, unless max streams
+// already are, in which case it reports false and the stream must be
+// refused. A max of zero means no limit.
+func acquireSSEStream(max int) bool {
+	for {
+		n := activeSSEStreams.Load()
+		if max > 0 && n >= int64(max) {
+			return false
+		}
+		if activeSSEStreams.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
 }
 
 func serveSSE(wr http.ResponseWriter, req *http.Request, sendServerHostname bool) {
@@ -288,15 +1302,50 @@ func serveSSE(wr http.ResponseWriter, req *http.Request, sendServerHostname bool
 		return
 	}
 
+	// Refuse the stream before starting it once SSE_MAX_CONNECTIONS streams
+	// are being served
+	maxStreams, _ := envInt("SSE_MAX_CONNECTIONS") // Validated at startup by validateConfig
+	if !acquireSSEStream(maxStreams) {
+		fmt.Printf("%s | sse | refused, %d streams already active\n", req.RemoteAddr, maxStreams)
+		writeError(wr, http.StatusServiceUnavailable, "Too many SSE streams")
+		return
+	}
+	defer activeSSEStreams.Add(-1)
+
 	var echo strings.Builder
 	writeRequest(&echo, req)
 
 	wr.Header().Set("Content-Type", "text/event-stream")
 	wr.Header().Set("Cache-Control", "no-cache")
 	wr.Header().Set("Connection", "keep-alive")
-	wr.Header().Set("Access-Control-Allow-Origin", "*")
+	setCORSHeaders(wr, req)
 
-	var id int
+	nonce := echoNonce(req)
+	if nonce != "" {
+		wr.Header().Set(echoNonceHeader, nonce)
+	}
+
+	// Continue the event ids from the Last-Event-ID of a reconnecting client,
+	// so they stay monotonic across reconnects
+	id := lastEventID(req)
+	resumedFrom := id
+
+	// Tell the client how long to wait before reconnecting.
+	if retry, _ := envDuration("SSE_RETRY"); retry > 0 { // Validated at startup by validateConfig
+		writeSSEField(wr, req, "retry", strconv.FormatInt(retry.Milliseconds(), 10))
+		fmt.Fprintf(wr, "\n")
+	}
+
+	// Write an event carrying the client's correlation nonce.
+	if nonce != "" {
+		writeSSE(
+			wr,
+			req,
+			&id,
+			"nonce",
+			nonce,
+		)
+	}
 
 	// Write an event about the server that is serving this request.
 	if sendServerHostname {
@@ -320,13 +1369,34 @@ func serveSSE(wr http.ResponseWriter, req *http.Request, sendServerHostname bool
 		echo.String(),
 	)
 
-	// Then send a counter event every second.
+	// Then send a counter event every second, until a configured limit ends
+	// the stream. SSE_MAX_EVENTS counts the initial nonce, server and request
+	// events above as well as the time events.
+	maxEvents, _ := envInt("SSE_MAX_EVENTS")
+	maxDuration, _ := envDuration("SSE_MAX_DURATION")
+
+	var expired <-chan time.Time
+	if maxDuration > 0 {
+		timer := time.NewTimer(maxDuration)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
 	for {
+		if maxEvents > 0 && id-resumedFrom >= maxEvents {
+			closeSSE(wr, req, &id, resumedFrom, "max events reached")
+			return
+		}
+
 		select {
 		case <-req.Context().Done():
+			fmt.Printf("%s | sse | client disconnected after %d event(s)\n", req.RemoteAddr, id-resumedFrom)
+			return
+		case <-expired:
+			closeSSE(wr, req, &id, resumedFrom, "max duration reached")
 			return
 		case t := <-ticker.C:
 			writeSSE(
@@ -340,6 +1410,37 @@ func serveSSE(wr http.ResponseWriter, req *http.Request, sendServerHostname bool
 	}
 }
 
+// closeSSE sends a final "close" event carrying the reason the server is
+// ending the stream, whose ids started after resumedFrom.
+func closeSSE(wr http.ResponseWriter, req *http.Request, id *int, resumedFrom int, reason string) {
+	writeSSE(
+		wr,
+		req,
+		id,
+		"close",
+		reason,
+	)
+	fmt.Printf("%s | sse | stream closed after %d event(s): %s\n", req.RemoteAddr, *id-resumedFrom, reason)
+}
+
+// lastEventID returns the id of the last event a reconnecting SSE client
+// received, from its Last-Event-ID header. It returns 0, starting the ids
+// afresh, when the header is missing or not a non-negative integer.
+func lastEventID(req *http.Request) int {
+	v := req.Header.Get("Last-Event-ID")
+	if v == "" {
+		return 0
+	}
+
+	id, err := strconv.Atoi(v)
+	if err != nil || id < 0 {
+		fmt.Printf("%s | sse | ignoring invalid Last-Event-ID %q\n", req.RemoteAddr, v)
+		return 0
+	}
+	fmt.Printf("%s | sse | resuming after event %d\n", req.RemoteAddr, id)
+	return id
+}
+
 // writeSSE sends a server-sent event and logs it to the console.
 func writeSSE(
 	wr http.ResponseWriter,
@@ -367,21 +1468,78 @@ func writeSSEField(
 	}
 }
 
+// receivedAtKey is the request context key of the time the echo handler
+// received the request.
+type receivedAtKey struct{}
+
 // writeRequest writes request headers to w.
 func writeRequest(w io.Writer, req *http.Request) {
 	fmt.Fprintf(w, "%s %s %s\n", req.Method, req.URL, req.Proto)
+	if original, ok := req.Context().Value(rewrittenFromKey{}).(string); ok {
+		fmt.Fprintf(w, "Rewritten from: %s\n", original)
+	}
 	fmt.Fprintln(w, "")
 
 	fmt.Fprintf(w, "Host: %s\n", req.Host)
 	printHeaders(w, req.Header)
 
-	var body bytes.Buffer
-	io.Copy(&body, req.Body) // nolint:errcheck
+	raw, _ := io.ReadAll(req.Body)
+	body, note := decodeRequestBody(req, raw)
+
+	if len(body) > 0 {
+		fmt.Fprintln(w, "")
+		if note != "" {
+			fmt.Fprintln(w, note)
+		}
+
+		limit, _ := envInt("MAX_ECHO_BODY_BYTES") // Validated at startup by validateConfig
+		if truncated, ok := truncateEchoBody(body, limit); ok {
+			w.Write(truncated) // nolint:errcheck
+			fmt.Fprintf(w, "\n...(truncated, %d bytes total)\n", len(body))
+		} else {
+			w.Write(body) // nolint:errcheck
+		}
+	}
 
-	if body.Len() > 0 {
+	if receivedAt, ok := req.Context().Value(receivedAtKey{}).(time.Time); ok && envBool("SEND_TIMESTAMP") {
 		fmt.Fprintln(w, "")
-		body.WriteTo(w) // nolint:errcheck
+		fmt.Fprintf(w, "Received at: %s\n", receivedAt.UTC().Format(time.RFC3339Nano))
+	}
+
+	if envBool("SEND_CONN_INFO") {
+		writeConnInfo(w, req)
+	}
+
+	if envBool("SEND_ORIGIN_INFO") {
+		writeOriginInfo(w, req)
 	}
+
+	if envBool("SEND_UPGRADE_INFO") {
+		writeUpgradeInfo(w, req)
+	}
+
+	if envBool("SEND_FORWARDED_URL") {
+		writeForwardedURL(w, req)
+	}
+
+	if req.TLS != nil && envBool("SEND_TLS_CLIENT_HELLO") {
+		writeClientHello(w, req)
+	}
+}
+
+// truncateEchoBody returns body cut to at most limit bytes, and whether it was
+// cut at all. A limit of zero means no limit. The cut is moved back to the
+// start of a UTF-8 sequence so no character is split.
+func truncateEchoBody(body []byte, limit int) ([]byte, bool) {
+	if limit == 0 || len(body) <= limit {
+		return body, false
+	}
+
+	n := limit
+	for n > 0 && n > limit-utf8.UTFMax && !utf8.RuneStart(body[n]) {
+		n--
+	}
+	return body[:n], true
 }
 
 func printHeaders(w io.Writer, h http.Header) {
@@ -400,15 +1558,56 @@ func printHeaders(w io.Writer, h http.Header) {
 	}
 }
 
-// throwErrorHandler throws an error with the given status code from the query param
+// throwErrorHandler throws an error with the given status code from the query param.
+// The error is JSON unless "format" is "text", and its message can be
+// replaced with the "message" query parameter.
 func throwErrorHandler(w http.ResponseWriter, r *http.Request) {
-	codeStr := r.URL.Query().Get("code")
+	query := r.URL.Query()
+
+	codeStr := query.Get("code")
 	code, err := strconv.Atoi(codeStr)
 	if err != nil || code < 100 || code > 599 {
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, `{"error":"Invalid status code"}`)
+		writeError(w, http.StatusBadRequest, "Invalid status code")
 		return
 	}
-	w.WriteHeader(code)
-	fmt.Fprintf(w, `{"error":"This is a forced error with status %d"}`, code)
+
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "text" {
+		writeError(w, http.StatusBadRequest, "Invalid format")
+		return
+	}
+
+	message := fmt.Sprintf("This is a forced error with status %d", code)
+	if v, ok := query["message"]; ok {
+		if !isValidThrowMessage(v[0]) {
+			writeError(w, http.StatusBadRequest, "Invalid message")
+			return
+		}
+		message = v[0]
+	}
+
+	if format == "text" {
+		// Never let a browser sniff the message as HTML
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(code)
+		fmt.Fprintln(w, message)
+		return
+	}
+
+	// The JSON encoder escapes quotes as well as HTML characters
+	writeError(w, code, message)
+}
+
+// maxThrowMessageLength caps the length of a custom /throw error message.
+const maxThrowMessageLength = 1024
+
+// isValidThrowMessage reports whether message can be used as a /throw error
+// message: non-empty valid UTF-8 of limited length without control
+// characters, which could otherwise inject extra lines into the response.
+func isValidThrowMessage(message string) bool {
+	if message == "" || len(message) > maxThrowMessageLength || !utf8.ValidString(message) {
+		return false
+	}
+	return !strings.ContainsFunc(message, unicode.IsControl)
 }
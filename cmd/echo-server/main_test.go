@@ -12,9 +12,11 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -25,7 +27,10 @@ import (
 	"golang.org/x/net/http2"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -61,8 +66,9 @@ func setupTestServers() {
 		// Start HTTP server
 		go func() {
 			server := &http.Server{
-				Addr:    ":" + testHTTPPort,
-				Handler: createRouter(),
+				Addr:        ":" + testHTTPPort,
+				Handler:     createRouter(),
+				ConnContext: connContext,
 			}
 
 			if err := server.ListenAndServe(); err != nil {
@@ -157,7 +163,7 @@ func TestHTTPEcho(t *testing.T) {
 		{
 			name:       "Custom headers echoed",
 			method:     "GET",
-			path:       "/headers",
+			path:       "/custom-headers",
 			headers:    map[string]string{"X-Custom-Header": "test-value"},
 			wantStatus: http.StatusOK,
 			checkBody: func(t *testing.T, body string) {
@@ -712,3 +718,1463 @@ func TestThrowErrorHandler(t *testing.T) {
 
 	t.Log("TestThrowErrorHandler passed")
 }
+
+// TestThrowErrorFormats verifies the text format and custom messages of the throw endpoint
+func TestThrowErrorFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+		wantType string
+		wantBody string
+	}{
+		{"Text format", "?code=503&format=text", 503, "text/plain; charset=utf-8", "This is a forced error with status 503\n"},
+		{"Custom message", "?code=418&message=" + url.QueryEscape(`I'm a "teapot"`), 418, "application/json", `{"error":"I'm a \"teapot\""}`},
+		{"Escaped HTML", "?code=400&message=" + url.QueryEscape("<b>bold</b>"), 400, "application/json", `{"error":"\u003cb\u003ebold\u003c/b\u003e"}`},
+		{"Custom text message", "?code=500&format=text&message=Boom", 500, "text/plain; charset=utf-8", "Boom\n"},
+		{"Injected line", "?code=500&format=text&message=" + url.QueryEscape("a\r\nb"), 400, "", `{"error":"Invalid message"}`},
+		{"Invalid format", "?code=500&format=xml", 400, "", `{"error":"Invalid format"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/throw"+tt.query, nil)
+			rw := httptest.NewRecorder()
+			throwErrorHandler(rw, req)
+
+			resp := rw.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantCode {
+				t.Errorf("expected status %d, got %d", tt.wantCode, resp.StatusCode)
+			}
+
+			if tt.wantType != "" && resp.Header.Get("Content-Type") != tt.wantType {
+				t.Errorf("expected Content-Type %q, got %q", tt.wantType, resp.Header.Get("Content-Type"))
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if strings.HasPrefix(tt.wantBody, "{") {
+				// Compare JSON independently of indentation
+				var got, want map[string]interface{}
+				json.Unmarshal(body, &got)
+				json.Unmarshal([]byte(tt.wantBody), &want)
+				if got["error"] != want["error"] {
+					t.Errorf("expected error %q, got %q", want["error"], got["error"])
+				}
+				if strings.Contains(string(body), "<") {
+					t.Errorf("expected HTML characters to be escaped, got %q", body)
+				}
+			} else if string(body) != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, body)
+			}
+		})
+	}
+
+	t.Log("TestThrowErrorFormats passed")
+}
+
+// TestWebSocketCompression verifies permessage-deflate negotiation and echo
+func TestWebSocketCompression(t *testing.T) {
+	wsURL := "ws://localhost:" + testHTTPPort + "/ws"
+	dialer := websocket.Dialer{EnableCompression: true}
+
+	t.Run("Compression negotiated", func(t *testing.T) {
+		conn, resp, err := dialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("failed to connect to WebSocket: %v", err)
+		}
+		defer conn.Close()
+
+		ext := resp.Header.Get("Sec-Websocket-Extensions")
+		if !strings.Contains(ext, "permessage-deflate") {
+			t.Fatalf("expected permessage-deflate to be negotiated, got %q", ext)
+		}
+
+		// Skip the greeting
+		conn.SetReadDeadline(time.Now().Add(1 * time.Second))
+		_, _, _ = conn.ReadMessage()
+
+		message := strings.Repeat("compress me please ", 1000)
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+			t.Fatalf("failed to send message: %v", err)
+		}
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, received, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("failed to read message: %v", err)
+		}
+
+		if string(received) != message {
+			t.Errorf("echoed message does not match (got %d bytes, want %d)", len(received), len(message))
+		}
+	})
+
+	t.Run("Compression disabled", func(t *testing.T) {
+		t.Setenv("WS_COMPRESSION", "false")
+
+		conn, resp, err := dialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("failed to connect to WebSocket: %v", err)
+		}
+		defer conn.Close()
+
+		if ext := resp.Header.Get("Sec-Websocket-Extensions"); ext != "" {
+			t.Errorf("expected no extensions to be negotiated, got %q", ext)
+		}
+	})
+
+	t.Log("TestWebSocketCompression passed")
+}
+
+// TestServerSentEventsDisconnect verifies the server cleans up when an SSE client disconnects
+func TestServerSentEventsDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", httpBaseURL+"/disconnect/.sse", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Wait for the first event so the stream is known to be active
+	if _, err := bufio.NewReader(resp.Body).ReadString('\n'); err != nil {
+		t.Fatalf("failed to read SSE stream: %v", err)
+	}
+
+	if n := activeSSEStreams.Load(); n < 1 {
+		t.Fatalf("expected at least 1 active SSE stream, got %d", n)
+	}
+
+	cancel()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for activeSSEStreams.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected no active SSE streams after disconnect, got %d", activeSSEStreams.Load())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Log("TestServerSentEventsDisconnect passed")
+}
+
+// TestServerSentEventsResume verifies a reconnecting client's Last-Event-ID resumes the event ids
+func TestServerSentEventsResume(t *testing.T) {
+	// firstFields opens a stream and returns the first value of each field
+	// until the first event id
+	firstFields := func(t *testing.T, lastEventID string) map[string]string {
+		t.Helper()
+
+		req, err := http.NewRequest("GET", httpBaseURL+"/resume/.sse", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		fields := map[string]string{}
+		reader := bufio.NewReader(resp.Body)
+		for fields["id"] == "" {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("failed to read SSE stream: %v", err)
+			}
+			if k, v, ok := strings.Cut(strings.TrimSpace(line), ": "); ok {
+				if _, seen := fields[k]; !seen {
+					fields[k] = v
+				}
+			}
+		}
+		return fields
+	}
+
+	t.Run("Fresh stream", func(t *testing.T) {
+		if id := firstFields(t, "")["id"]; id != "1" {
+			t.Errorf("expected the first event id to be 1, got %s", id)
+		}
+	})
+
+	t.Run("Resumed", func(t *testing.T) {
+		if id := firstFields(t, "41")["id"]; id != "42" {
+			t.Errorf("expected the first event id to continue from 41, got %s", id)
+		}
+	})
+
+	t.Run("Invalid Last-Event-ID", func(t *testing.T) {
+		if id := firstFields(t, "abc")["id"]; id != "1" {
+			t.Errorf("expected the ids to start afresh, got %s", id)
+		}
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		t.Setenv("SSE_RETRY", "2500ms")
+
+		if retry := firstFields(t, "")["retry"]; retry != "2500" {
+			t.Errorf("expected retry 2500, got %q", retry)
+		}
+	})
+
+	t.Run("Max events counts only this stream", func(t *testing.T) {
+		t.Setenv("SSE_MAX_EVENTS", "1")
+
+		req, err := http.NewRequest("GET", httpBaseURL+"/resume/.sse", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("Last-Event-ID", "100")
+
+		resp, err := (&http.Client{Timeout: 5 * time.Second}).Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("failed to read SSE stream: %v", err)
+		}
+		if !strings.Contains(string(body), "data: max events reached") {
+			t.Errorf("expected the stream to close after its own events, got: %s", body)
+		}
+	})
+
+	t.Log("TestServerSentEventsResume passed")
+}
+
+// TestServerSentEventsMaxConnections verifies streams beyond SSE_MAX_CONNECTIONS are refused with 503
+func TestServerSentEventsMaxConnections(t *testing.T) {
+	t.Setenv("SSE_MAX_CONNECTIONS", "2")
+
+	// Let streams of earlier tests notice their clients went away
+	deadline := time.Now().Add(3 * time.Second)
+	for activeSSEStreams.Load() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected no active SSE streams before the test, got %d", activeSSEStreams.Load())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	open := func(t *testing.T) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(ctx, "GET", httpBaseURL+"/limited/.sse", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		return resp
+	}
+
+	for i := 0; i < 2; i++ {
+		resp := open(t)
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected stream %d to be accepted, got status %d", i+1, resp.StatusCode)
+		}
+		// Wait for the first event so the stream is known to be active
+		if _, err := bufio.NewReader(resp.Body).ReadString('\n'); err != nil {
+			t.Fatalf("failed to read SSE stream: %v", err)
+		}
+	}
+
+	resp := open(t)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 beyond the limit, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct == "text/event-stream" {
+		t.Errorf("expected the refused stream not to start, got Content-Type %q", ct)
+	}
+	if n := activeSSEStreams.Load(); n != 2 {
+		t.Errorf("expected the refused stream not to count as active, got %d active", n)
+	}
+
+	t.Log("TestServerSentEventsMaxConnections passed")
+}
+
+// TestGRPCWarmup verifies calls fail with Unavailable until the warmup period elapses
+func TestGRPCWarmup(t *testing.T) {
+	server := &grpcEchoServer{readyAt: time.Now().Add(300 * time.Millisecond)}
+	req := &echo.EchoRequest{Message: "warmup"}
+
+	_, err := server.Echo(context.Background(), req)
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable during warmup, got %v", err)
+	}
+
+	time.Sleep(400 * time.Millisecond)
+
+	resp, err := server.Echo(context.Background(), req)
+	if err != nil {
+		t.Fatalf("expected call to succeed after warmup, got %v", err)
+	}
+
+	if resp.Message != req.Message {
+		t.Errorf("expected %q, got %q", req.Message, resp.Message)
+	}
+
+	t.Log("TestGRPCWarmup passed")
+}
+
+// TestWebSocketCloseReasons verifies clean and abnormal closures are counted separately
+func TestWebSocketCloseReasons(t *testing.T) {
+	wsURL := "ws://localhost:" + testHTTPPort + "/ws"
+
+	// waitForCounter polls until the counter exceeds its initial value
+	waitForCounter := func(t *testing.T, counter *atomic.Int64, initial int64) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for counter.Load() <= initial {
+			if time.Now().After(deadline) {
+				t.Fatalf("counter did not increase from %d", initial)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	t.Run("Clean closure", func(t *testing.T) {
+		initial := wsClosedNormally.Load()
+
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("failed to connect to WebSocket: %v", err)
+		}
+		defer conn.Close()
+
+		err = conn.WriteMessage(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "bye"),
+		)
+		if err != nil {
+			t.Fatalf("failed to send close message: %v", err)
+		}
+
+		waitForCounter(t, &wsClosedNormally, initial)
+	})
+
+	t.Run("Abnormal closure", func(t *testing.T) {
+		initial := wsClosedUnexpectedly.Load()
+
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("failed to connect to WebSocket: %v", err)
+		}
+
+		// Drop the TCP connection without sending a close frame
+		conn.UnderlyingConn().Close()
+
+		waitForCounter(t, &wsClosedUnexpectedly, initial)
+	})
+
+	t.Log("TestWebSocketCloseReasons passed")
+}
+
+// TestEchoNonce verifies the X-Echo-Nonce header round-trips over HTTP, SSE and WebSocket
+func TestEchoNonce(t *testing.T) {
+	const nonce = "nonce-1234"
+
+	t.Run("HTTP", func(t *testing.T) {
+		req, err := http.NewRequest("GET", httpBaseURL+"/nonce", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("X-Echo-Nonce", nonce)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if v := resp.Header.Get("X-Echo-Nonce"); v != nonce {
+			t.Errorf("expected nonce header %q, got %q", nonce, v)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		if !strings.HasPrefix(string(body), "Nonce: "+nonce+"\n") {
+			t.Errorf("expected body to start with the nonce, got: %s", body)
+		}
+	})
+
+	t.Run("SSE", func(t *testing.T) {
+		req, err := http.NewRequest("GET", httpBaseURL+"/nonce/.sse", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("X-Echo-Nonce", nonce)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if v := resp.Header.Get("X-Echo-Nonce"); v != nonce {
+			t.Errorf("expected nonce header %q, got %q", nonce, v)
+		}
+
+		reader := bufio.NewReader(resp.Body)
+		event, _ := reader.ReadString('\n')
+		data, _ := reader.ReadString('\n')
+
+		if event != "event: nonce\n" || data != "data: "+nonce+"\n" {
+			t.Errorf("expected nonce event first, got %q %q", event, data)
+		}
+	})
+
+	t.Run("WebSocket", func(t *testing.T) {
+		header := http.Header{"X-Echo-Nonce": {nonce}}
+		conn, resp, err := websocket.DefaultDialer.Dial("ws://localhost:"+testHTTPPort+"/ws", header)
+		if err != nil {
+			t.Fatalf("failed to connect to WebSocket: %v", err)
+		}
+		defer conn.Close()
+
+		if v := resp.Header.Get("X-Echo-Nonce"); v != nonce {
+			t.Errorf("expected nonce header %q, got %q", nonce, v)
+		}
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, greeting, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("failed to read greeting: %v", err)
+		}
+
+		if !strings.HasSuffix(string(greeting), "Nonce: "+nonce) {
+			t.Errorf("expected greeting to contain the nonce, got %q", greeting)
+		}
+	})
+
+	t.Log("TestEchoNonce passed")
+}
+
+// TestContentTypeOverride verifies the echo Content-Type can be forced via query parameter
+func TestContentTypeOverride(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantType   string
+	}{
+		{"default", "", http.StatusOK, "text/plain"},
+		{"xml", "?content-type=application/xml", http.StatusOK, "application/xml"},
+		{"with parameters", "?content-type=text/html%3B%20charset%3Dutf-8", http.StatusOK, "text/html; charset=utf-8"},
+		{"missing subtype", "?content-type=application", http.StatusBadRequest, "application/json"},
+		{"garbage", "?content-type=%2F%2F%2F", http.StatusBadRequest, "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(httpBaseURL + "/content-type" + tt.query)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			if ct := resp.Header.Get("Content-Type"); ct != tt.wantType {
+				t.Errorf("expected Content-Type %q, got %q", tt.wantType, ct)
+			}
+		})
+	}
+
+	t.Log("TestContentTypeOverride passed")
+}
+
+// TestPprofEndpoints verifies the pprof handlers are only mounted when enabled
+func TestPprofEndpoints(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     string
+		wantProfile bool
+	}{
+		{"enabled", "true", true},
+		{"disabled", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENABLE_PPROF", tt.enabled)
+
+			server := httptest.NewServer(createRouter())
+			defer server.Close()
+
+			resp, err := http.Get(server.URL + "/debug/pprof/")
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			isProfile := strings.Contains(string(body), "goroutine")
+			if isProfile != tt.wantProfile {
+				t.Errorf("expected profile index %v, got body: %s", tt.wantProfile, body)
+			}
+		})
+	}
+
+	t.Log("TestPprofEndpoints passed")
+}
+
+// TestNoContentPaths verifies body-less GETs to configured paths get 204
+func TestNoContentPaths(t *testing.T) {
+	t.Setenv("NO_CONTENT_PATHS", "/empty/*, /ping")
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"matching glob", "GET", "/empty/foo", "", http.StatusNoContent},
+		{"matching exact path", "GET", "/ping", "", http.StatusNoContent},
+		{"non-matching path", "GET", "/other", "", http.StatusOK},
+		{"GET with body", "GET", "/ping", "payload", http.StatusOK},
+		{"POST", "POST", "/ping", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+
+			req, err := http.NewRequest(tt.method, httpBaseURL+tt.path, body)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	t.Log("TestNoContentPaths passed")
+}
+
+// TestServerSentEventsLimits verifies streams end with a close event once a limit is reached
+func TestServerSentEventsLimits(t *testing.T) {
+	// readStream reads the whole stream, which the server ends on its own
+	readStream := func(t *testing.T) string {
+		t.Helper()
+
+		client := &http.Client{Timeout: 5 * time.Second}
+		resp, err := client.Get(httpBaseURL + "/limits/.sse")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read SSE stream: %v", err)
+		}
+		return string(body)
+	}
+
+	t.Run("Max events", func(t *testing.T) {
+		t.Setenv("SSE_MAX_EVENTS", "4")
+		body := readStream(t)
+
+		// 4 events, then the close event
+		if events := strings.Count(body, "event: "); events != 5 {
+			t.Errorf("expected 5 events, got %d: %s", events, body)
+		}
+		if !strings.HasSuffix(body, "event: close\ndata: max events reached\nid: 5\n\n") {
+			t.Errorf("expected final close event, got: %s", body)
+		}
+	})
+
+	t.Run("Max duration", func(t *testing.T) {
+		t.Setenv("SSE_MAX_DURATION", "300ms")
+		body := readStream(t)
+
+		if strings.Contains(body, "event: time") {
+			t.Errorf("expected stream to end before the first time event, got: %s", body)
+		}
+		if !strings.Contains(body, "event: close\ndata: max duration reached\n") {
+			t.Errorf("expected final close event, got: %s", body)
+		}
+	})
+
+	t.Log("TestServerSentEventsLimits passed")
+}
+
+// TestGRPCDeadline verifies the remaining time to the call deadline is reported in response metadata
+func TestGRPCDeadline(t *testing.T) {
+	conn, err := grpc.Dial(
+		grpcAddress,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to create gRPC client: %v", err)
+	}
+	defer conn.Close()
+
+	client := echo.NewEchoClient(conn)
+
+	t.Run("With deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var header metadata.MD
+		if _, err := client.Echo(ctx, &echo.EchoRequest{Message: "deadline"}, grpc.Header(&header)); err != nil {
+			t.Fatalf("failed to call Echo: %v", err)
+		}
+
+		values := header.Get(grpcDeadlineHeader)
+		if len(values) != 1 {
+			t.Fatalf("expected one %s value, got %v", grpcDeadlineHeader, values)
+		}
+
+		remaining, err := time.ParseDuration(values[0])
+		if err != nil {
+			t.Fatalf("failed to parse remaining time %q: %v", values[0], err)
+		}
+		if remaining <= 4*time.Second || remaining > 5*time.Second {
+			t.Errorf("expected remaining time between 4s and 5s, got %s", remaining)
+		}
+	})
+
+	t.Run("Without deadline", func(t *testing.T) {
+		var header metadata.MD
+		if _, err := client.Echo(context.Background(), &echo.EchoRequest{Message: "deadline"}, grpc.Header(&header)); err != nil {
+			t.Fatalf("failed to call Echo: %v", err)
+		}
+
+		if values := header.Get(grpcDeadlineHeader); len(values) != 1 || values[0] != "none" {
+			t.Errorf("expected %s to be \"none\", got %v", grpcDeadlineHeader, values)
+		}
+	})
+
+	t.Log("TestGRPCDeadline passed")
+}
+
+// TestWebSocketWriteTimeout verifies the server closes connections whose client stops reading
+func TestWebSocketWriteTimeout(t *testing.T) {
+	t.Setenv("WS_WRITE_TIMEOUT", "200ms")
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://localhost:"+testHTTPPort+"/ws", nil)
+	if err != nil {
+		t.Fatalf("failed to connect to WebSocket: %v", err)
+	}
+	defer conn.Close()
+
+	// Read the greeting, then flood the server with messages without reading
+	// the echoes, until the socket buffers fill up and its writes block
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("failed to read greeting: %v", err)
+	}
+
+	initial := wsClosedUnexpectedly.Load()
+
+	go func() {
+		payload := bytes.Repeat([]byte("x"), 1<<20)
+		conn.SetWriteDeadline(time.Now().Add(5 * time.Second)) // nolint:errcheck
+		for i := 0; i < 64; i++ {
+			if err := conn.WriteMessage(websocket.BinaryMessage, payload); err != nil {
+				return
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for wsClosedUnexpectedly.Load() <= initial {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the server to close the connection after the write timeout")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Log("TestWebSocketWriteTimeout passed")
+}
+
+// TestDownloadDisposition verifies ?download= sets a sanitized Content-Disposition
+func TestDownloadDisposition(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		wantStatus      int
+		wantDisposition string
+	}{
+		{"No download", "", http.StatusOK, ""},
+		{"Plain name", "?download=filename.txt", http.StatusOK, `attachment; filename="filename.txt"`},
+		{"Path and quotes", "?download=" + url.QueryEscape(`../etc/pa"ss"wd`), http.StatusOK, `attachment; filename="passwd"`},
+		{"Header injection", "?download=" + url.QueryEscape("a.txt\r\nSet-Cookie: x=y"), http.StatusOK, `attachment; filename="a.txtSet-Cookie: x=y"`},
+		{"Empty name", "?download=", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(httpBaseURL + "/download" + tt.query)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			if cd := resp.Header.Get("Content-Disposition"); cd != tt.wantDisposition {
+				t.Errorf("expected Content-Disposition %q, got %q", tt.wantDisposition, cd)
+			}
+
+			if resp.Header.Get("Set-Cookie") != "" {
+				t.Error("expected no injected Set-Cookie header")
+			}
+		})
+	}
+
+	t.Log("TestDownloadDisposition passed")
+}
+
+// TestMaxEchoBodyBytes verifies echoed bodies over MAX_ECHO_BODY_BYTES are truncated with a note
+func TestMaxEchoBodyBytes(t *testing.T) {
+	t.Setenv("MAX_ECHO_BODY_BYTES", "10")
+
+	post := func(t *testing.T, body string) string {
+		t.Helper()
+
+		resp, err := http.Post(httpBaseURL+"/truncated", "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		echoed, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+		return string(echoed)
+	}
+
+	t.Run("Over the limit", func(t *testing.T) {
+		body := post(t, strings.Repeat("x", 100))
+
+		if !strings.Contains(body, "\n"+strings.Repeat("x", 10)+"\n...(truncated, 100 bytes total)\n") {
+			t.Errorf("expected the body truncated to 10 bytes, got: %s", body)
+		}
+		if strings.Contains(body, strings.Repeat("x", 11)) {
+			t.Errorf("expected no more than 10 bytes of the body, got: %s", body)
+		}
+	})
+
+	t.Run("Within the limit", func(t *testing.T) {
+		body := post(t, "short")
+
+		if !strings.HasSuffix(body, "\nshort") || strings.Contains(body, "...(truncated") {
+			t.Errorf("expected the body echoed whole, got: %s", body)
+		}
+	})
+
+	t.Run("Multi-byte characters", func(t *testing.T) {
+		// "é" is two bytes, so the 10-byte limit falls inside the fifth one
+		body := post(t, "aéééééé")
+
+		if !strings.Contains(body, "\naéééé\n...(truncated, 13 bytes total)") {
+			t.Errorf("expected the cut before a whole character, got: %s", body)
+		}
+	})
+
+	t.Log("TestMaxEchoBodyBytes passed")
+}
+
+// TestMethodStatusOverrides verifies echo responses use the per-method status codes
+func TestMethodStatusOverrides(t *testing.T) {
+	t.Setenv("ECHO_POST_STATUS", "201")
+	t.Setenv("ECHO_DELETE_STATUS", "204")
+
+	tests := []struct {
+		method     string
+		wantStatus int
+	}{
+		{"POST", http.StatusCreated},
+		{"DELETE", http.StatusNoContent},
+		{"GET", http.StatusOK},
+		{"PUT", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, httpBaseURL+"/method-status", nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	t.Run("Invalid override", func(t *testing.T) {
+		t.Setenv("ECHO_PATCH_STATUS", "99")
+
+		if _, err := methodStatuses(); err == nil {
+			t.Error("expected error for an out-of-range status code")
+		}
+	})
+
+	t.Log("TestMethodStatusOverrides passed")
+}
+
+// TestAllowedMethods verifies echoes of OPTIONS requests list the configured methods in Allow
+func TestAllowedMethods(t *testing.T) {
+	options := func(t *testing.T) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest("OPTIONS", httpBaseURL+"/allowed", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		allow := options(t).Header.Get("Allow")
+		if allow != "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS" {
+			t.Errorf("expected the default Allow header, got %q", allow)
+		}
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		t.Setenv("ALLOWED_METHODS", "GET, PROPFIND")
+
+		resp := options(t)
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+		if allow := resp.Header.Get("Allow"); allow != "GET, PROPFIND" {
+			t.Errorf("expected Allow \"GET, PROPFIND\", got %q", allow)
+		}
+	})
+
+	t.Run("Not on other methods", func(t *testing.T) {
+		resp, err := http.Get(httpBaseURL + "/allowed")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		if allow := resp.Header.Get("Allow"); allow != "" {
+			t.Errorf("expected no Allow header on GET, got %q", allow)
+		}
+	})
+
+	t.Run("Invalid method", func(t *testing.T) {
+		t.Setenv("ALLOWED_METHODS", "GET, BAD METHOD")
+
+		if _, err := allowedMethods(); err == nil {
+			t.Error("expected error for a method name with a space")
+		}
+	})
+
+	t.Log("TestAllowedMethods passed")
+}
+
+// TestPetStoreIdempotencyKey verifies a repeated Idempotency-Key replays the created pet
+func TestPetStoreIdempotencyKey(t *testing.T) {
+	// createPet creates a pet with the given Idempotency-Key
+	createPet := func(t *testing.T, baseURL, key, name string) (openapi.Pet, *http.Response) {
+		t.Helper()
+
+		req, err := http.NewRequest("POST", baseURL+"/v1/pets", strings.NewReader(`{"name":"`+name+`"}`))
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			t.Errorf("expected status 201, got %d", resp.StatusCode)
+		}
+
+		var pet openapi.Pet
+		if err := json.NewDecoder(resp.Body).Decode(&pet); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return pet, resp
+	}
+
+	t.Run("Same key replays", func(t *testing.T) {
+		first, resp := createPet(t, httpBaseURL, "create-nibbles", "Nibbles")
+		if resp.Header.Get("Idempotency-Replayed") != "" {
+			t.Error("expected no Idempotency-Replayed header on the first request")
+		}
+
+		second, resp := createPet(t, httpBaseURL, "create-nibbles", "Nibbles")
+		if resp.Header.Get("Idempotency-Replayed") != "true" {
+			t.Error("expected Idempotency-Replayed: true on the repeated request")
+		}
+
+		if second != first {
+			t.Errorf("expected the replayed pet %+v, got %+v", first, second)
+		}
+	})
+
+	t.Run("Different key creates", func(t *testing.T) {
+		first, _ := createPet(t, httpBaseURL, "create-a", "Twin")
+		second, _ := createPet(t, httpBaseURL, "create-b", "Twin")
+
+		if first.ID == second.ID {
+			t.Errorf("expected distinct pets, both got ID %d", first.ID)
+		}
+	})
+
+	t.Run("Expired key creates", func(t *testing.T) {
+		t.Setenv("IDEMPOTENCY_TTL", "100ms")
+
+		server := httptest.NewServer(createRouter())
+		defer server.Close()
+
+		first, _ := createPet(t, server.URL, "create-expiring", "Fleeting")
+		time.Sleep(150 * time.Millisecond)
+		second, resp := createPet(t, server.URL, "create-expiring", "Fleeting")
+
+		if first.ID == second.ID || resp.Header.Get("Idempotency-Replayed") != "" {
+			t.Errorf("expected a new pet after the key expired, got ID %d twice", first.ID)
+		}
+	})
+
+	t.Log("TestPetStoreIdempotencyKey passed")
+}
+
+// TestWebSocketFrameDelays verifies text and binary frames are echoed with their own delays
+func TestWebSocketFrameDelays(t *testing.T) {
+	wsURL := "ws://localhost:" + testHTTPPort + "/ws"
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+"?text-delay=300ms&bin-delay=0s", nil)
+	if err != nil {
+		t.Fatalf("failed to connect to WebSocket: %v", err)
+	}
+	defer conn.Close()
+
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("failed to read greeting: %v", err)
+	}
+
+	// echoTime returns how long the echo of a frame takes
+	echoTime := func(t *testing.T, messageType int) time.Duration {
+		t.Helper()
+
+		start := time.Now()
+		if err := conn.WriteMessage(messageType, []byte("frame")); err != nil {
+			t.Fatalf("failed to write message: %v", err)
+		}
+		if _, _, err := conn.ReadMessage(); err != nil {
+			t.Fatalf("failed to read echo: %v", err)
+		}
+		return time.Since(start)
+	}
+
+	textTime := echoTime(t, websocket.TextMessage)
+	binaryTime := echoTime(t, websocket.BinaryMessage)
+
+	if textTime < 300*time.Millisecond {
+		t.Errorf("expected text echo to take at least 300ms, took %s", textTime)
+	}
+	if binaryTime >= textTime {
+		t.Errorf("expected binary echo (%s) to be faster than text echo (%s)", binaryTime, textTime)
+	}
+
+	t.Run("Invalid delay", func(t *testing.T) {
+		_, resp, err := websocket.DefaultDialer.Dial(wsURL+"?bin-delay=soon", nil)
+		if err == nil {
+			t.Fatal("expected the handshake to fail")
+		}
+		if resp == nil || resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %v", resp)
+		}
+	})
+
+	t.Log("TestWebSocketFrameDelays passed")
+}
+
+// TestPetStoreStrictContentType verifies creates with the wrong Content-Type get 415 in strict mode
+func TestPetStoreStrictContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		strict      string
+		contentType string
+		wantStatus  int
+	}{
+		{"Lenient wrong type", "", "text/plain", http.StatusCreated},
+		{"Strict wrong type", "true", "text/plain", http.StatusUnsupportedMediaType},
+		{"Strict missing type", "true", "", http.StatusUnsupportedMediaType},
+		{"Strict JSON with charset", "true", "application/json; charset=utf-8", http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("STRICT_CONTENT_TYPE", tt.strict)
+
+			server := httptest.NewServer(createRouter())
+			defer server.Close()
+
+			req, err := http.NewRequest("POST", server.URL+"/v1/pets", strings.NewReader(`{"name":"Typed"}`))
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+		})
+	}
+
+	t.Log("TestPetStoreStrictContentType passed")
+}
+
+// TestReceiptTimestamp verifies the server receipt time is echoed with nanosecond precision
+func TestReceiptTimestamp(t *testing.T) {
+	t.Setenv("SEND_TIMESTAMP", "true")
+	t.Setenv("DELAY", "200ms")
+
+	sent := time.Now()
+	resp, err := http.Get(httpBaseURL + "/timestamp")
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read response body: %v", err)
+	}
+
+	_, value, ok := strings.Cut(string(body), "\nReceived at: ")
+	if !ok {
+		t.Fatalf("expected a receipt timestamp, got: %s", body)
+	}
+	value, _, _ = strings.Cut(value, "\n")
+
+	receivedAt, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		t.Fatalf("failed to parse timestamp %q: %v", value, err)
+	}
+
+	// The timestamp is taken on arrival, before the configured delay
+	if d := receivedAt.Sub(sent); d < -time.Second || d >= 200*time.Millisecond {
+		t.Errorf("expected the timestamp to precede the delay, got %s after sending", d)
+	}
+
+	t.Log("TestReceiptTimestamp passed")
+}
+
+// TestGRPCMaxConcurrentStreams verifies calls beyond the stream limit wait and still complete
+func TestGRPCMaxConcurrentStreams(t *testing.T) {
+	t.Setenv("GRPC_MAX_CONCURRENT_STREAMS", "2")
+
+	server, err := newGRPCServer()
+	if err != nil {
+		t.Fatalf("failed to create gRPC server: %v", err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go server.Serve(lis) // nolint:errcheck
+	defer server.Stop()
+
+	conn, err := grpc.Dial(
+		lis.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to create gRPC client: %v", err)
+	}
+	defer conn.Close()
+
+	client := echo.NewEchoClient(conn)
+
+	const calls = 50
+	errs := make(chan error, calls)
+
+	for i := 0; i < calls; i++ {
+		go func(i int) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			message := fmt.Sprintf("call %d", i)
+			resp, err := client.Echo(ctx, &echo.EchoRequest{Message: message})
+			if err == nil && resp.Message != message {
+				err = fmt.Errorf("expected %q, got %q", message, resp.Message)
+			}
+			errs <- err
+		}(i)
+	}
+
+	for i := 0; i < calls; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("call failed: %v", err)
+		}
+	}
+
+	t.Log("TestGRPCMaxConcurrentStreams passed")
+}
+
+// TestPetStoreMax verifies creates beyond PETSTORE_MAX are rejected
+func TestPetStoreMax(t *testing.T) {
+	// The two sample pets count towards the limit
+	t.Setenv("PETSTORE_MAX", "4")
+
+	server := httptest.NewServer(createRouter())
+	defer server.Close()
+
+	for i, wantStatus := range []int{
+		http.StatusCreated,
+		http.StatusCreated,
+		http.StatusInsufficientStorage,
+	} {
+		resp, err := http.Post(server.URL+"/v1/pets", "application/json", strings.NewReader(`{"name":"Pet"}`))
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != wantStatus {
+			t.Errorf("create %d: expected status %d, got %d", i+1, wantStatus, resp.StatusCode)
+		}
+
+		if wantStatus == http.StatusInsufficientStorage {
+			var apiErr openapi.Error
+			if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
+				t.Fatalf("failed to decode error response: %v", err)
+			}
+			if apiErr.Code != http.StatusInsufficientStorage {
+				t.Errorf("expected error code 507, got %d", apiErr.Code)
+			}
+		}
+	}
+
+	t.Log("TestPetStoreMax passed")
+}
+
+// TestWebSocketTicks verifies periodic server messages arrive interleaved with echoes
+func TestWebSocketTicks(t *testing.T) {
+	conn, _, err := websocket.DefaultDialer.Dial("ws://localhost:"+testHTTPPort+"/ws?tick=50ms", nil)
+	if err != nil {
+		t.Fatalf("failed to connect to WebSocket: %v", err)
+	}
+	defer conn.Close()
+
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("failed to read greeting: %v", err)
+	}
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("echo me")); err != nil {
+		t.Fatalf("failed to write message: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second)) // nolint:errcheck
+
+	var ticks int
+	var echoed bool
+	for ticks < 3 || !echoed {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("failed to read message (ticks: %d, echoed: %v): %v", ticks, echoed, err)
+		}
+
+		switch {
+		case string(message) == "echo me":
+			echoed = true
+		case strings.HasPrefix(string(message), fmt.Sprintf("Tick %d at ", ticks+1)):
+			ticks++
+		default:
+			t.Fatalf("unexpected message %q", message)
+		}
+	}
+
+	t.Run("Invalid interval", func(t *testing.T) {
+		_, resp, err := websocket.DefaultDialer.Dial("ws://localhost:"+testHTTPPort+"/ws?tick=1ns", nil)
+		if err == nil {
+			t.Fatal("expected the handshake to fail")
+		}
+		if resp == nil || resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %v", resp)
+		}
+	})
+
+	t.Log("TestWebSocketTicks passed")
+}
+
+// TestWebSocketMaxMessages verifies the server closes the connection with a reason after echoing max-messages messages
+func TestWebSocketMaxMessages(t *testing.T) {
+	conn, _, err := websocket.DefaultDialer.Dial("ws://localhost:"+testHTTPPort+"/ws?max-messages=2", nil)
+	if err != nil {
+		t.Fatalf("failed to connect to WebSocket: %v", err)
+	}
+	defer conn.Close()
+
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("failed to read greeting: %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second)) // nolint:errcheck
+
+	for i := 1; i <= 2; i++ {
+		msg := fmt.Sprintf("message %d", i)
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+			t.Fatalf("failed to write message %d: %v", i, err)
+		}
+		if _, message, err := conn.ReadMessage(); err != nil || string(message) != msg {
+			t.Fatalf("expected echo %q, got %q (%v)", msg, message, err)
+		}
+	}
+
+	// The third message is never echoed: the server has closed the session
+	conn.WriteMessage(websocket.TextMessage, []byte("message 3")) // nolint:errcheck
+
+	_, message, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("expected a normal close, got message %q and error %v", message, err)
+	}
+	if closeErr := err.(*websocket.CloseError); closeErr.Text != "max-messages 2 reached" {
+		t.Errorf("expected close reason %q, got %q", "max-messages 2 reached", closeErr.Text)
+	}
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		_, resp, err := websocket.DefaultDialer.Dial("ws://localhost:"+testHTTPPort+"/ws?max-messages=0", nil)
+		if err == nil {
+			t.Fatal("expected the handshake to fail")
+		}
+		if resp == nil || resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %v", resp)
+		}
+	})
+
+	t.Log("TestWebSocketMaxMessages passed")
+}
+
+// TestFrontendWebSocketURL verifies the .ws frontend connects with the scheme and host the page was served on
+func TestFrontendWebSocketURL(t *testing.T) {
+	fetch := func(t *testing.T, client *http.Client, url string, header http.Header) string {
+		t.Helper()
+
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header = header
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+		return string(body)
+	}
+
+	t.Run("Behind TLS", func(t *testing.T) {
+		server := httptest.NewTLSServer(createRouter())
+		defer server.Close()
+
+		body := fetch(t, server.Client(), server.URL+"/.ws", nil)
+		want := "url = 'wss://" + strings.TrimPrefix(server.URL, "https://") + "/';"
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	})
+
+	t.Run("Plain HTTP", func(t *testing.T) {
+		body := fetch(t, http.DefaultClient, httpBaseURL+"/.ws", nil)
+		want := "url = 'ws://localhost:" + testHTTPPort + "/';"
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	})
+
+	t.Run("Forwarded", func(t *testing.T) {
+		header := http.Header{"X-Forwarded-Proto": {"https"}, "X-Forwarded-Host": {"echo.example.com"}}
+		body := fetch(t, http.DefaultClient, httpBaseURL+"/app/.ws", header)
+		want := "url = 'wss://echo.example.com/app';"
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	})
+
+	t.Run("Untrusted proxy", func(t *testing.T) {
+		t.Setenv("TRUST_PROXY", "false")
+
+		header := http.Header{"X-Forwarded-Proto": {"https"}, "X-Forwarded-Host": {"echo.example.com"}}
+		body := fetch(t, http.DefaultClient, httpBaseURL+"/.ws", header)
+		want := "url = 'ws://localhost:" + testHTTPPort + "/';"
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		t.Setenv("WEBSOCKET_SCHEME", "wss")
+		t.Setenv("WEBSOCKET_HOST", "ws.example.com:8443")
+
+		body := fetch(t, http.DefaultClient, httpBaseURL+"/.ws", nil)
+		want := "url = 'wss://ws.example.com:8443/';"
+		if !strings.Contains(body, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	})
+
+	t.Log("TestFrontendWebSocketURL passed")
+}
+
+// TestFlushImmediately verifies FLUSH_IMMEDIATELY sends the headers before the body is buffered
+func TestFlushImmediately(t *testing.T) {
+	tests := []struct {
+		name        string
+		flush       string
+		wantChunked bool
+	}{
+		// Flushing the headers commits the response before its length is
+		// known, so the body follows in chunks
+		{"Flushed", "true", true},
+		{"Buffered", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FLUSH_IMMEDIATELY", tt.flush)
+
+			resp, err := http.Get(httpBaseURL + "/flush")
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			chunked := len(resp.TransferEncoding) > 0 && resp.TransferEncoding[0] == "chunked"
+			if chunked != tt.wantChunked {
+				t.Errorf("expected chunked %v, got transfer encoding %v and content length %d",
+					tt.wantChunked, resp.TransferEncoding, resp.ContentLength)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			if !strings.Contains(string(body), "GET /flush HTTP/1.1") {
+				t.Errorf("expected the request to be echoed, got: %s", body)
+			}
+		})
+	}
+
+	t.Log("TestFlushImmediately passed")
+}
+
+// TestPetStoreErrorRate verifies PETSTORE_ERROR_RATE fails petstore calls with a 500 Error
+func TestPetStoreErrorRate(t *testing.T) {
+	t.Setenv("PETSTORE_ERROR_RATE", "1.0")
+
+	server := httptest.NewServer(createRouter())
+	defer server.Close()
+
+	calls := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"GET", "/v1/pets", ""},
+		{"POST", "/v1/pets", `{"name":"Flaky"}`},
+		{"GET", "/v1/pets/1", ""},
+	}
+
+	for _, call := range calls {
+		t.Run(call.method+" "+call.path, func(t *testing.T) {
+			req, err := http.NewRequest(call.method, server.URL+call.path, strings.NewReader(call.body))
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusInternalServerError {
+				t.Fatalf("expected status 500, got %d", resp.StatusCode)
+			}
+
+			var body struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if body.Code != http.StatusInternalServerError || body.Message == "" {
+				t.Errorf("expected a 500 Error body, got %+v", body)
+			}
+		})
+	}
+
+	t.Log("TestPetStoreErrorRate passed")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// negotiateMediaType picks the media type to respond with given the client's
+// Accept header and the types the server can produce, in preference order.
+// Each offer takes the q-value of the most specific matching Accept range
+// ("text/plain" over "text/*" over "*/*"); the highest q-value wins and ties
+// are broken by the server order. Without an Accept header the first offer
+// is used. It returns an empty string if none of the offers is acceptable.
+func negotiateMediaType(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	// qualities maps each media range to its q-value
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		qualities[mediaRange] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		typ, _, _ := strings.Cut(offer, "/")
+
+		for _, mediaRange := range []string{offer, typ + "/*", "*/*"} {
+			if q, ok := qualities[mediaRange]; ok {
+				if q > bestQ {
+					best, bestQ = offer, q
+				}
+				break
+			}
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestNegotiateMediaType verifies Accept header negotiation
+func TestNegotiateMediaType(t *testing.T) {
+	offers := []string{"application/json", "text/plain"}
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"text/plain", "text/plain"},
+		{"text/*", "text/plain"},
+		{"application/json;q=0.5, text/plain", "text/plain"},
+		{"text/html, */*;q=0.8", "application/json"},
+		{"*/*;q=0.9, text/plain;q=0", "application/json"},
+		{"text/html", ""},
+	}
+
+	for _, tt := range tests {
+		if got := negotiateMediaType(tt.accept, offers); got != tt.want {
+			t.Errorf("negotiateMediaType(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+
+	t.Log("TestNegotiateMediaType passed")
+}
+
+// TestHealthCheckNegotiation verifies the health endpoint answers in the accepted format
+func TestHealthCheckNegotiation(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		wantType string
+		wantBody string
+	}{
+		{"Default", "", "application/json", `"status":"healthy"`},
+		{"JSON", "application/json", "application/json", `"status":"healthy"`},
+		{"Plain text", "text/plain", "text/plain; charset=utf-8", "healthy\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", httpBaseURL+"/health", nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if ct := resp.Header.Get("Content-Type"); ct != tt.wantType {
+				t.Errorf("expected Content-Type %q, got %q", tt.wantType, ct)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("expected body to contain %q, got %q", tt.wantBody, body)
+			}
+		})
+	}
+
+	t.Log("TestHealthCheckNegotiation passed")
+}
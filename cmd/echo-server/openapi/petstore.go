@@ -2,9 +2,13 @@ package openapi
 
 import (
 	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"mime"
 	"net/http"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -22,18 +26,50 @@ type Error struct {
 	Message string `json:"message"`
 }
 
+// DefaultIdempotencyTTL is how long an Idempotency-Key is remembered by default
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// maxIdempotencyKeys bounds the number of remembered Idempotency-Keys
+const maxIdempotencyKeys = 10000
+
+// idempotencyEntry records the pet created for an Idempotency-Key
+type idempotencyEntry struct {
+	petID   int64
+	expires time.Time
+}
+
 // PetStore manages the pets collection
 type PetStore struct {
-	mu     sync.RWMutex
-	pets   map[int64]*Pet
-	nextID int64
+	// IdempotencyTTL is how long an Idempotency-Key is remembered
+	IdempotencyTTL time.Duration
+
+	// MaxPets caps the number of pets in the store, or 0 for no limit
+	MaxPets int
+
+	// StrictContentType rejects creates whose Content-Type is not
+	// application/json with 415 instead of attempting to decode them
+	StrictContentType bool
+
+	// ErrorRate is the fraction of calls failed with a 500 by InjectErrors
+	ErrorRate float64
+
+	// WriteError, if set, writes error responses in place of the default
+	// Error body
+	WriteError func(w http.ResponseWriter, code int, message string)
+
+	mu              sync.RWMutex
+	pets            map[int64]*Pet
+	nextID          int64
+	idempotencyKeys map[string]idempotencyEntry
 }
 
 // NewPetStore creates a new PetStore instance
 func NewPetStore() *PetStore {
 	store := &PetStore{
-		pets:   make(map[int64]*Pet),
-		nextID: 1,
+		IdempotencyTTL:  DefaultIdempotencyTTL,
+		pets:            make(map[int64]*Pet),
+		nextID:          1,
+		idempotencyKeys: make(map[string]idempotencyEntry),
 	}
 	// Add some sample pets
 	store.pets[1] = &Pet{ID: 1, Name: "Fluffy", Tag: "cat"}
@@ -79,6 +115,14 @@ func (ps *PetStore) CreatePets(w http.ResponseWriter, r *http.Request) {
 	// ps.setCORSHeaders(w)
 	w.Header().Set("Content-Type", "application/json")
 
+	if ps.StrictContentType {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType != "application/json" {
+			ps.sendError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+			return
+		}
+	}
+
 	var pet Pet
 	if err := json.NewDecoder(r.Body).Decode(&pet); err != nil {
 		ps.sendError(w, http.StatusBadRequest, "Invalid request body")
@@ -90,16 +134,86 @@ func (ps *PetStore) CreatePets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A retried request with the same Idempotency-Key gets the pet created
+	// by the first one instead of a duplicate
+	key := r.Header.Get("Idempotency-Key")
+
 	ps.mu.Lock()
+	if existing, ok := ps.idempotentPet(key); ok {
+		replayed := *existing
+		ps.mu.Unlock()
+
+		w.Header().Set("Idempotency-Replayed", "true")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(replayed)
+		return
+	}
+
+	if ps.MaxPets > 0 && len(ps.pets) >= ps.MaxPets {
+		ps.mu.Unlock()
+		ps.sendError(w, http.StatusInsufficientStorage, "Pet store is full")
+		return
+	}
+
 	pet.ID = ps.nextID
 	ps.nextID++
 	ps.pets[pet.ID] = &pet
+	ps.rememberIdempotencyKey(key, pet.ID)
 	ps.mu.Unlock()
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(pet)
 }
 
+// idempotentPet returns the pet created for an unexpired Idempotency-Key.
+// The caller must hold ps.mu.
+func (ps *PetStore) idempotentPet(key string) (*Pet, bool) {
+	if key == "" {
+		return nil, false
+	}
+
+	entry, ok := ps.idempotencyKeys[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+
+	pet, ok := ps.pets[entry.petID]
+	return pet, ok
+}
+
+// rememberIdempotencyKey records the pet created for an Idempotency-Key.
+// When the store is full, expired keys are dropped first, then the key
+// closest to expiry. The caller must hold ps.mu.
+func (ps *PetStore) rememberIdempotencyKey(key string, petID int64) {
+	if key == "" {
+		return
+	}
+
+	now := time.Now()
+	if len(ps.idempotencyKeys) >= maxIdempotencyKeys {
+		for k, entry := range ps.idempotencyKeys {
+			if now.After(entry.expires) {
+				delete(ps.idempotencyKeys, k)
+			}
+		}
+	}
+
+	if len(ps.idempotencyKeys) >= maxIdempotencyKeys {
+		var oldest string
+		for k, entry := range ps.idempotencyKeys {
+			if oldest == "" || entry.expires.Before(ps.idempotencyKeys[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(ps.idempotencyKeys, oldest)
+	}
+
+	ps.idempotencyKeys[key] = idempotencyEntry{
+		petID:   petID,
+		expires: now.Add(ps.IdempotencyTTL),
+	}
+}
+
 // ShowPetById handles GET /pets/{petId}
 func (ps *PetStore) ShowPetById(w http.ResponseWriter, r *http.Request) {
 	// ps.setCORSHeaders(w)
@@ -125,8 +239,27 @@ func (ps *PetStore) ShowPetById(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(pet)
 }
 
+// InjectErrors is a middleware that fails the fraction ErrorRate of calls
+// with a 500 Error, simulating a flaky API backend
+func (ps *PetStore) InjectErrors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ps.ErrorRate > 0 && rand.Float64() < ps.ErrorRate {
+			fmt.Printf("%s | chaos | petstore injected 500 for %s %s\n", r.RemoteAddr, r.Method, r.URL)
+			w.Header().Set("Content-Type", "application/json")
+			ps.sendError(w, http.StatusInternalServerError, "Injected failure")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // sendError sends an error response
 func (ps *PetStore) sendError(w http.ResponseWriter, code int, message string) {
+	if ps.WriteError != nil {
+		ps.WriteError(w, code, message)
+		return
+	}
+
 	w.WriteHeader(code)
 	json.NewEncoder(w).Encode(Error{
 		Code:    int32(code),
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// postmanSchema is the Postman collection format that ?format=postman
+// echoes are in.
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanCollection is a Postman collection holding a single request item,
+// which both Postman and Insomnia can import.
+type postmanCollection struct {
+	Info postmanInfo   `json:"info"`
+	Item []postmanItem `json:"item"`
+}
+
+// postmanInfo names a Postman collection and its schema.
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// postmanItem is a named request in a Postman collection.
+type postmanItem struct {
+	Name    string         `json:"name"`
+	Request postmanRequest `json:"request"`
+}
+
+// postmanRequest is a request in the Postman collection format.
+type postmanRequest struct {
+	Method string          `json:"method"`
+	Header []postmanKV     `json:"header"`
+	URL    postmanURL      `json:"url"`
+	Body   *postmanRawBody `json:"body,omitempty"`
+}
+
+// postmanKV is a header or query parameter of a Postman request.
+type postmanKV struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// postmanURL is the URL of a Postman request, both raw and split into parts.
+type postmanURL struct {
+	Raw      string      `json:"raw"`
+	Protocol string      `json:"protocol"`
+	Host     []string    `json:"host"`
+	Port     string      `json:"port,omitempty"`
+	Path     []string    `json:"path"`
+	Query    []postmanKV `json:"query,omitempty"`
+}
+
+// postmanRawBody is a Postman request body sent as is.
+type postmanRawBody struct {
+	Mode string `json:"mode"`
+	Raw  string `json:"raw"`
+}
+
+// newPostmanCollection maps the structured echo doc of req to a Postman
+// collection with req as its only item. Headers are sorted by name, and the
+// URL is the absolute one the request was received on, without the format
+// query parameter.
+func newPostmanCollection(req *http.Request, doc echoDocument) postmanCollection {
+	u, _ := url.Parse(requestURL(req))
+
+	names := make([]string, 0, len(doc.Headers))
+	for name := range doc.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := []postmanKV{}
+	for _, name := range names {
+		for _, value := range doc.Headers[name] {
+			headers = append(headers, postmanKV{Key: name, Value: value})
+		}
+	}
+
+	// Keep the query parameters in order, leaving out the format parameter
+	// that asked for this echo
+	var query []postmanKV
+	var kept []string
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		key, value, _ := strings.Cut(pair, "=")
+		k, _ := url.QueryUnescape(key)
+		if pair == "" || k == "format" {
+			continue
+		}
+		v, _ := url.QueryUnescape(value)
+		query = append(query, postmanKV{Key: k, Value: v})
+		kept = append(kept, pair)
+	}
+	u.RawQuery = strings.Join(kept, "&")
+
+	request := postmanRequest{
+		Method: doc.Method,
+		Header: headers,
+		URL: postmanURL{
+			Raw:      u.String(),
+			Protocol: u.Scheme,
+			Host:     strings.Split(u.Hostname(), "."),
+			Port:     u.Port(),
+			Path:     strings.Split(strings.TrimPrefix(u.Path, "/"), "/"),
+			Query:    query,
+		},
+	}
+	if doc.Body != "" {
+		request.Body = &postmanRawBody{Mode: "raw", Raw: doc.Body}
+	}
+
+	name := doc.Method + " " + u.Path
+	return postmanCollection{
+		Info: postmanInfo{Name: "echo-server: " + name, Schema: postmanSchema},
+		Item: []postmanItem{{Name: name, Request: request}},
+	}
+}
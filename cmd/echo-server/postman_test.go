@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestPostmanEcho verifies ?format=postman returns the request as an importable Postman collection
+func TestPostmanEcho(t *testing.T) {
+	req, err := http.NewRequest("POST", httpBaseURL+"/orders/42?format=postman&expand=items", strings.NewReader(`{"qty":2}`))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Custom", "value")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %q", ct)
+	}
+
+	var collection postmanCollection
+	if err := json.NewDecoder(resp.Body).Decode(&collection); err != nil {
+		t.Fatalf("failed to decode collection: %v", err)
+	}
+
+	if collection.Info.Schema != postmanSchema {
+		t.Errorf("expected schema %q, got %q", postmanSchema, collection.Info.Schema)
+	}
+	if len(collection.Item) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(collection.Item))
+	}
+
+	request := collection.Item[0].Request
+	if request.Method != "POST" {
+		t.Errorf("expected method POST, got %q", request.Method)
+	}
+
+	wantRaw := httpBaseURL + "/orders/42?expand=items"
+	if request.URL.Raw != wantRaw {
+		t.Errorf("expected raw URL %q, got %q", wantRaw, request.URL.Raw)
+	}
+	if request.URL.Protocol != "http" || request.URL.Port != testHTTPPort || strings.Join(request.URL.Path, "/") != "orders/42" {
+		t.Errorf("unexpected URL parts: %+v", request.URL)
+	}
+	if len(request.URL.Query) != 1 || request.URL.Query[0] != (postmanKV{Key: "expand", Value: "items"}) {
+		t.Errorf("expected only the expand query parameter, got %v", request.URL.Query)
+	}
+
+	found := false
+	for _, h := range request.Header {
+		if h == (postmanKV{Key: "X-Custom", Value: "value"}) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the X-Custom header, got %v", request.Header)
+	}
+
+	if request.Body == nil || request.Body.Mode != "raw" || request.Body.Raw != `{"qty":2}` {
+		t.Errorf("expected the raw body, got %+v", request.Body)
+	}
+
+	t.Log("TestPostmanEcho passed")
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// isH2CUpgradeRequest reports whether req asked for an h2c upgrade the way
+// the h2c wrapper around the router accepts it: h2c among the Upgrade
+// offers and HTTP2-Settings among the Connection tokens. As the wrapper
+// fails such requests itself when the upgrade does not succeed, one that
+// reaches the echo handler was upgraded and is answered over HTTP/2.
+func isH2CUpgradeRequest(req *http.Request) bool {
+	return req.ProtoMajor == 1 &&
+		containsToken(headerTokens(req.Header, "Upgrade"), "h2c") &&
+		containsToken(headerTokens(req.Header, "Connection"), "HTTP2-Settings")
+}
+
+// negotiatedProtocol describes the protocol req was received with and how
+// it was negotiated, for LOG_PROTOCOL. Cleartext HTTP/2 is either h2c with
+// prior knowledge or a stream on a connection that was upgraded from
+// HTTP/1.1; the connection is marked as upgraded when its upgrade request is
+// seen, so later streams on it can be told apart.
+func negotiatedProtocol(req *http.Request) string {
+	info, _ := req.Context().Value(connInfoKey{}).(*connInfo)
+
+	switch {
+	case req.ProtoMajor == 3:
+		return "HTTP/3 over QUIC"
+	case req.ProtoMajor == 2 && req.TLS != nil:
+		return "HTTP/2 over TLS (ALPN h2)"
+	case req.ProtoMajor == 2 && info != nil && info.h2cUpgraded.Load():
+		return "h2c on a connection upgraded from HTTP/1.1"
+	case req.ProtoMajor == 2:
+		return "h2c with prior knowledge"
+	case isH2CUpgradeRequest(req):
+		if info != nil {
+			info.h2cUpgraded.Store(true)
+		}
+		return "h2c upgrade: sent as HTTP/1.1, answered over HTTP/2"
+	case req.TLS != nil && req.TLS.NegotiatedProtocol != "":
+		return fmt.Sprintf("%s over TLS (ALPN %s)", req.Proto, req.TLS.NegotiatedProtocol)
+	case req.TLS != nil:
+		return fmt.Sprintf("%s over TLS (no ALPN)", req.Proto)
+	default:
+		return req.Proto
+	}
+}
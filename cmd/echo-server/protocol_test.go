@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// captureStdout returns what fn prints to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	captured := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r) // nolint:errcheck
+		captured <- buf.String()
+	}()
+
+	fn()
+
+	w.Close()
+	return <-captured
+}
+
+// TestLogProtocol verifies LOG_PROTOCOL logs how the protocol of each request was negotiated
+func TestLogProtocol(t *testing.T) {
+	t.Setenv("LOG_PROTOCOL", "true")
+
+	t.Run("HTTP/1.1", func(t *testing.T) {
+		out := captureStdout(t, func() {
+			resp, err := http.Get(httpBaseURL + "/protocol-h1")
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			resp.Body.Close()
+		})
+
+		if !strings.Contains(out, "| protocol | HTTP/1.1\n") {
+			t.Errorf("expected HTTP/1.1 to be logged, got: %s", out)
+		}
+	})
+
+	t.Run("h2c with prior knowledge", func(t *testing.T) {
+		client := &http.Client{
+			Transport: &http2.Transport{
+				AllowHTTP: true,
+				DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, network, addr)
+				},
+			},
+		}
+
+		out := captureStdout(t, func() {
+			resp, err := client.Get(httpBaseURL + "/protocol-h2c")
+			if err != nil {
+				t.Fatalf("failed to make HTTP/2 request: %v", err)
+			}
+			resp.Body.Close()
+		})
+
+		if !strings.Contains(out, "| protocol | h2c with prior knowledge\n") {
+			t.Errorf("expected h2c with prior knowledge to be logged, got: %s", out)
+		}
+	})
+
+	t.Run("h2c upgrade", func(t *testing.T) {
+		conn, err := net.DialTimeout("tcp", "localhost:"+testHTTPPort, 3*time.Second)
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(3 * time.Second)) // nolint:errcheck
+
+		out := captureStdout(t, func() {
+			// Offer the upgrade with empty settings, then send the client
+			// preface the server expects once it switched protocols
+			io.WriteString(conn, "GET /protocol-upgrade HTTP/1.1\r\n"+ // nolint:errcheck
+				"Host: localhost\r\n"+
+				"Connection: Upgrade, HTTP2-Settings\r\n"+
+				"Upgrade: h2c\r\n"+
+				"HTTP2-Settings: \r\n\r\n")
+
+			reader := bufio.NewReader(conn)
+			resp, err := http.ReadResponse(reader, nil)
+			if err != nil {
+				t.Fatalf("failed to read upgrade response: %v", err)
+			}
+			if resp.StatusCode != http.StatusSwitchingProtocols {
+				t.Fatalf("expected status 101, got %d", resp.StatusCode)
+			}
+
+			io.WriteString(conn, http2.ClientPreface) // nolint:errcheck
+			framer := http2.NewFramer(conn, reader)
+			framer.WriteSettings() // nolint:errcheck
+
+			// waitForResponse reads frames until the response headers on the
+			// given stream
+			waitForResponse := func(streamID uint32) {
+				for {
+					frame, err := framer.ReadFrame()
+					if err != nil {
+						t.Fatalf("failed to read HTTP/2 frame: %v", err)
+					}
+					if _, ok := frame.(*http2.HeadersFrame); ok && frame.Header().StreamID == streamID {
+						return
+					}
+				}
+			}
+
+			// The upgraded request is answered on stream 1
+			waitForResponse(1)
+
+			// Then make another request on the upgraded connection
+			var block bytes.Buffer
+			enc := hpack.NewEncoder(&block)
+			for _, f := range []hpack.HeaderField{
+				{Name: ":method", Value: "GET"},
+				{Name: ":scheme", Value: "http"},
+				{Name: ":authority", Value: "localhost"},
+				{Name: ":path", Value: "/protocol-upgraded"},
+			} {
+				enc.WriteField(f) // nolint:errcheck
+			}
+			framer.WriteHeaders(http2.HeadersFrameParam{ // nolint:errcheck
+				StreamID:      3,
+				BlockFragment: block.Bytes(),
+				EndStream:     true,
+				EndHeaders:    true,
+			})
+			waitForResponse(3)
+		})
+
+		if !strings.Contains(out, "| protocol | h2c upgrade: sent as HTTP/1.1, answered over HTTP/2\n") {
+			t.Errorf("expected the h2c upgrade to be logged, got: %s", out)
+		}
+		if !strings.Contains(out, "| protocol | h2c on a connection upgraded from HTTP/1.1\n") {
+			t.Errorf("expected the next request on the upgraded connection to be logged, got: %s", out)
+		}
+	})
+
+	t.Log("TestLogProtocol passed")
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"net"
+	"time"
+
+	"github.com/pires/go-proxyproto"
+)
+
+// proxyHeaderTimeout bounds how long a new connection may take to send its
+// PROXY protocol header.
+const proxyHeaderTimeout = 10 * time.Second
+
+// proxyProtocolListener wraps lis so connections may start with a PROXY
+// protocol v1 or v2 header, as sent by HAProxy or an AWS NLB, and report the
+// client address it carries as their remote address. Connections without a
+// header are accepted unchanged.
+func proxyProtocolListener(lis net.Listener) net.Listener {
+	return &proxyproto.Listener{
+		Listener:          lis,
+		ReadHeaderTimeout: proxyHeaderTimeout,
+	}
+}
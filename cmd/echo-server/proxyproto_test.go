@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/pires/go-proxyproto"
+)
+
+// TestProxyProtocol verifies the client address is taken from the PROXY protocol header
+func TestProxyProtocol(t *testing.T) {
+	t.Setenv("PROXY_PROTOCOL", "true")
+
+	lis, err := listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	server := &http.Server{Handler: createRouter()}
+	go server.Serve(lis) // nolint:errcheck
+	defer server.Close()
+
+	v2Header := proxyproto.HeaderProxyFromAddrs(2,
+		&net.TCPAddr{IP: net.ParseIP("198.51.100.9"), Port: 40000},
+		&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 80},
+	)
+	v2Preamble, err := v2Header.Format()
+	if err != nil {
+		t.Fatalf("failed to format PROXY v2 header: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		preamble   string
+		wantOrigin string
+	}{
+		{"PROXY v1", "PROXY TCP4 203.0.113.7 10.0.0.1 51234 80\r\n", "203.0.113.7"},
+		{"PROXY v2", string(v2Preamble), "198.51.100.9"},
+		{"No header", "", "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", lis.Addr().String())
+			if err != nil {
+				t.Fatalf("failed to connect: %v", err)
+			}
+			defer conn.Close()
+
+			request := "GET /get HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"
+			if _, err := conn.Write([]byte(tt.preamble + request)); err != nil {
+				t.Fatalf("failed to write request: %v", err)
+			}
+
+			resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			if err != nil {
+				t.Fatalf("failed to read response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var result struct {
+				Origin string `json:"origin"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if result.Origin != tt.wantOrigin {
+				t.Errorf("expected origin %q, got %q", tt.wantOrigin, result.Origin)
+			}
+		})
+	}
+
+	t.Log("TestProxyProtocol passed")
+}
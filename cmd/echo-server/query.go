@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxQueryRows caps the number of rows returned by /query.
+const maxQueryRows = 10000
+
+// queryRow is a fake database row streamed by /query.
+type queryRow struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+// queryHandler handles GET /query?rows=N&latency=D, simulating a slow
+// database query: it waits for the latency (capped at MAX_DELAY), then
+// streams N (at most 10000) fake rows as newline-delimited JSON, flushing
+// each row as it is written. The query is abandoned when the client goes
+// away.
+func queryHandler(w http.ResponseWriter, r *http.Request) {
+	rows := 0
+	if v := r.URL.Query().Get("rows"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid rows %q", v))
+			return
+		}
+		rows = min(n, maxQueryRows)
+	}
+
+	var latency time.Duration
+	if v := r.URL.Query().Get("latency"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid latency %q", v))
+			return
+		}
+		cfg, _ := loadDelayConfig() // Validated at startup by validateConfig
+		latency = min(d, cfg.max)
+	}
+
+	fmt.Printf("%s | query | %d row(s) after %s\n", r.RemoteAddr, rows, latency)
+
+	select {
+	case <-r.Context().Done():
+		return
+	case <-time.After(latency):
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+
+	for i := 0; i < rows; i++ {
+		if r.Context().Err() != nil {
+			return
+		}
+
+		if err := enc.Encode(queryRow{ID: i + 1, Name: fmt.Sprintf("row-%d", i+1), Value: (i + 1) * 7 % 100}); err != nil {
+			return
+		}
+		rc.Flush() // nolint:errcheck
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestQueryEndpoint verifies /query waits for the latency and then streams the requested rows
+func TestQueryEndpoint(t *testing.T) {
+	t.Run("Rows and latency", func(t *testing.T) {
+		start := time.Now()
+		resp, err := http.Get(httpBaseURL + "/query?rows=25&latency=200ms")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if elapsed := time.Since(start); elapsed < 200*time.Millisecond || elapsed > 2*time.Second {
+			t.Errorf("expected the response to start after about 200ms, took %s", elapsed)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("expected Content-Type application/x-ndjson, got %q", ct)
+		}
+
+		var rows []queryRow
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var row queryRow
+			if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+				t.Fatalf("failed to decode row %q: %v", scanner.Text(), err)
+			}
+			rows = append(rows, row)
+		}
+
+		if len(rows) != 25 {
+			t.Fatalf("expected 25 rows, got %d", len(rows))
+		}
+		if rows[0].ID != 1 || rows[24].ID != 25 {
+			t.Errorf("expected rows numbered 1 to 25, got %d to %d", rows[0].ID, rows[24].ID)
+		}
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"rows=-1", "rows=many", "latency=soon"} {
+			resp, err := http.Get(httpBaseURL + "/query?" + query)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("expected status 400 for %s, got %d", query, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Log("TestQueryEndpoint passed")
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// rawHeaderCase returns the exact casing of the response header names listed
+// in the comma-separated RAW_HEADER_CASE, such as "content-type,X-REQUEST-ID",
+// keyed by their canonical form. It returns nil when unset.
+func rawHeaderCase() (map[string]string, error) {
+	var headerCase map[string]string
+	for _, name := range strings.Split(os.Getenv("RAW_HEADER_CASE"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !httpguts.ValidHeaderFieldName(name) {
+			return nil, fmt.Errorf("RAW_HEADER_CASE: %q is not a valid header name", name)
+		}
+
+		if headerCase == nil {
+			headerCase = map[string]string{}
+		}
+		headerCase[http.CanonicalHeaderKey(name)] = name
+	}
+	return headerCase, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRawHeaderCase verifies RAW_HEADER_CASE headers are written with their exact casing
+func TestRawHeaderCase(t *testing.T) {
+	t.Setenv("RAW_HEADER_CASE", "content-type, X-ECHO-nonce")
+
+	conn, err := net.DialTimeout("tcp", "localhost:"+testHTTPPort, 3*time.Second)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(3 * time.Second)) // nolint:errcheck
+
+	io.WriteString(conn, "GET /raw-case HTTP/1.1\r\nHost: localhost\r\nX-Echo-Nonce: n-1\r\n\r\n") // nolint:errcheck
+
+	// Read the raw header lines, as http.ReadResponse would canonicalize them
+	reader := bufio.NewReader(conn)
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		if line = strings.TrimRight(line, "\r\n"); line == "" {
+			break
+		}
+		lines = append(lines, line)
+	}
+	head := strings.Join(lines, "\n")
+
+	if !strings.HasPrefix(head, "HTTP/1.1 200 OK") {
+		t.Errorf("expected status 200, got: %s", head)
+	}
+	for _, want := range []string{"\ncontent-type: text/plain", "\nX-ECHO-nonce: n-1", "\nContent-Length: "} {
+		if !strings.Contains(head, want) {
+			t.Errorf("expected header line %q, got:\n%s", strings.TrimPrefix(want, "\n"), head)
+		}
+	}
+	if strings.Contains(head, "Content-Type:") {
+		t.Errorf("expected no canonical Content-Type, got:\n%s", head)
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if !strings.Contains(string(body), "GET /raw-case HTTP/1.1") {
+		t.Errorf("expected the request to be echoed, got: %s", body)
+	}
+
+	t.Run("Invalid name", func(t *testing.T) {
+		t.Setenv("RAW_HEADER_CASE", "bad header")
+		if _, err := rawHeaderCase(); err == nil {
+			t.Error("expected an error for a header name with a space")
+		}
+	})
+
+	t.Log("TestRawHeaderCase passed")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recoverMiddleware catches panics raised by HTTP handlers, logs the stack
+// trace to stderr and responds with a 500 JSON error instead of dropping the
+// connection. When the response had already started, a status can no longer
+// be sent, so the connection is aborted instead, as net/http does for
+// unrecovered panics. It is installed outermost in createRouter, so that it
+// also covers the handlers that run before routing.
+func recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &recoveryWriter{ResponseWriter: w}
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+
+			// http.ErrAbortHandler is used to deliberately abort a response,
+			// so let the server handle it as usual.
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			fmt.Fprintf(os.Stderr, "%s | panic | %s %s: %v\n%s", r.RemoteAddr, r.Method, r.URL, rec, debug.Stack())
+
+			if rw.started {
+				panic(http.ErrAbortHandler)
+			}
+			writeError(w, http.StatusInternalServerError, "Internal server error")
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// recoveryWriter tracks whether the response started, so recoverMiddleware
+// knows whether it can still answer with an error.
+type recoveryWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (rw *recoveryWriter) WriteHeader(code int) {
+	// Informational responses may be followed by the final one
+	if code >= http.StatusOK {
+		rw.started = true
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recoveryWriter) Write(b []byte) (int, error) {
+	rw.started = true
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *recoveryWriter) Flush() {
+	rw.started = true
+	http.NewResponseController(rw.ResponseWriter).Flush() // nolint:errcheck
+}
+
+// Hijack is implemented directly rather than through Unwrap, as the
+// WebSocket upgrader requires an http.Hijacker.
+func (rw *recoveryWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil {
+		rw.started = true
+	}
+	return conn, buf, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *recoveryWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// panicHandler handles /panic, mounted when ENABLE_PANIC_ENDPOINT is set,
+// by deliberately panicking with the "message" query parameter, to check
+// that recoverMiddleware answers with a 500 and the server keeps serving.
+func panicHandler(w http.ResponseWriter, r *http.Request) {
+	message := r.URL.Query().Get("message")
+	if message == "" {
+		message = "deliberate panic from /panic"
+	}
+	panic(message)
+}
+
+// recoveryUnaryInterceptor converts panics in unary gRPC handlers into an
+// Internal status error.
+func recoveryUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (resp interface{}, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			fmt.Fprintf(os.Stderr, "gRPC | panic | %s: %v\n%s", info.FullMethod, rec, debug.Stack())
+			err = status.Error(codes.Internal, "internal server error")
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+// recoveryStreamInterceptor converts panics in streaming gRPC handlers into an
+// Internal status error.
+func recoveryStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			fmt.Fprintf(os.Stderr, "gRPC | panic | %s: %v\n%s", info.FullMethod, rec, debug.Stack())
+			err = status.Error(codes.Internal, "internal server error")
+		}
+	}()
+
+	return handler(srv, ss)
+}
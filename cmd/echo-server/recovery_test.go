@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestRecoverMiddleware verifies that a panicking handler results in a 500 JSON error
+func TestRecoverMiddleware(t *testing.T) {
+	// Test-only router with a route that deliberately panics
+	r := mux.NewRouter()
+	r.Use(recoverMiddleware)
+	r.HandleFunc("/boom", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+	r.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	r.HandleFunc("/partial", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte("partial")) // nolint:errcheck
+		w.(http.Flusher).Flush()
+		panic("boom after the response started")
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/boom")
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", resp.StatusCode)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %s", ct)
+	}
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if _, ok := body["error"]; !ok {
+		t.Errorf("expected error field in response, got %v", body)
+	}
+
+	// The server must keep serving after a panic
+	okResp, err := http.Get(server.URL + "/ok")
+	if err != nil {
+		t.Fatalf("failed to make request after panic: %v", err)
+	}
+	okResp.Body.Close()
+
+	if okResp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200 after panic, got %d", okResp.StatusCode)
+	}
+
+	t.Run("Response started", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/partial")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		// The status already went out, so the connection is aborted rather
+		// than an error appended to the body
+		body, err := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || err == nil {
+			t.Errorf("expected an aborted 200 response, got status %d and error %v", resp.StatusCode, err)
+		}
+		if string(body) != "partial" {
+			t.Errorf("expected only the partial body, got %q", body)
+		}
+	})
+
+	t.Log("TestRecoverMiddleware passed")
+}
+
+// TestPanicEndpoint verifies /panic is only mounted when enabled and its panics are recovered
+func TestPanicEndpoint(t *testing.T) {
+	t.Run("Enabled", func(t *testing.T) {
+		t.Setenv("ENABLE_PANIC_ENDPOINT", "true")
+
+		server := httptest.NewServer(createRouter())
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/panic?message=kaboom")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusInternalServerError {
+			t.Errorf("expected status 500, got %d", resp.StatusCode)
+		}
+
+		var body map[string]string
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if body["error"] != "Internal server error" {
+			t.Errorf("expected a generic error, got %v", body)
+		}
+
+		// The server must keep serving after a panic
+		okResp, err := http.Get(server.URL + "/health")
+		if err != nil {
+			t.Fatalf("failed to make request after panic: %v", err)
+		}
+		okResp.Body.Close()
+
+		if okResp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200 after panic, got %d", okResp.StatusCode)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		server := httptest.NewServer(createRouter())
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/panic")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		// Without the endpoint, /panic is echoed like any other path
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Log("TestPanicEndpoint passed")
+}
+
+// TestRecoveryUnaryInterceptor verifies that a panicking gRPC handler returns Internal
+func TestRecoveryUnaryInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/echo.Echo/Echo"}
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		panic("boom")
+	}
+
+	_, err := recoveryUnaryInterceptor(context.Background(), nil, info, handler)
+	if status.Code(err) != codes.Internal {
+		t.Errorf("expected code Internal, got %v", status.Code(err))
+	}
+
+	t.Log("TestRecoveryUnaryInterceptor passed")
+}
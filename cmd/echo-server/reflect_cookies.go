@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+)
+
+// reflectCookies sets every cookie of req back on the response with
+// Set-Cookie when REFLECT_COOKIES is set, so clients can test their cookie
+// jar round-trips. Reflected cookies get Path=/ and, when
+// REFLECT_COOKIES_MAX_AGE is set, a Max-Age of that many seconds. Cookies
+// whose name or value could not be sent back safely are skipped.
+func reflectCookies(wr http.ResponseWriter, req *http.Request) {
+	if !envBool("REFLECT_COOKIES") {
+		return
+	}
+
+	maxAge, _ := envDuration("REFLECT_COOKIES_MAX_AGE") // Validated at startup by validateConfig
+	for _, c := range req.Cookies() {
+		reflected := &http.Cookie{
+			Name:   c.Name,
+			Value:  c.Value,
+			Quoted: c.Quoted,
+			Path:   "/",
+			MaxAge: int(maxAge.Seconds()),
+		}
+		if reflected.Valid() != nil {
+			continue
+		}
+		http.SetCookie(wr, reflected)
+	}
+}
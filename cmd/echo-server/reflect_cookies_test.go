@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestReflectCookies verifies request cookies are sent back as Set-Cookie when REFLECT_COOKIES is set
+func TestReflectCookies(t *testing.T) {
+	get := func(t *testing.T, cookies ...*http.Cookie) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest("GET", httpBaseURL+"/cookies-reflected", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		resp := get(t, &http.Cookie{Name: "session", Value: "abc123"})
+		if v := resp.Header.Values("Set-Cookie"); len(v) != 0 {
+			t.Errorf("expected no Set-Cookie, got %v", v)
+		}
+	})
+
+	t.Run("Reflected", func(t *testing.T) {
+		t.Setenv("REFLECT_COOKIES", "true")
+
+		resp := get(t, &http.Cookie{Name: "session", Value: "abc123"}, &http.Cookie{Name: "theme", Value: "dark"})
+
+		want := []string{"session=abc123; Path=/", "theme=dark; Path=/"}
+		got := resp.Header.Values("Set-Cookie")
+		if len(got) != len(want) {
+			t.Fatalf("expected Set-Cookie %v, got %v", want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("expected Set-Cookie %q, got %q", want[i], got[i])
+			}
+		}
+	})
+
+	t.Run("Max-Age", func(t *testing.T) {
+		t.Setenv("REFLECT_COOKIES", "true")
+		t.Setenv("REFLECT_COOKIES_MAX_AGE", "1h")
+
+		resp := get(t, &http.Cookie{Name: "session", Value: "abc123"})
+		if got := resp.Header.Get("Set-Cookie"); got != "session=abc123; Path=/; Max-Age=3600" {
+			t.Errorf("unexpected Set-Cookie %q", got)
+		}
+	})
+
+	t.Run("Unsafe values", func(t *testing.T) {
+		t.Setenv("REFLECT_COOKIES", "true")
+
+		req, err := http.NewRequest("GET", httpBaseURL+"/cookies-reflected", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("Cookie", `ok=1; bad"name=2; evil=a\b`)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		if got := resp.Header.Values("Set-Cookie"); len(got) != 1 || got[0] != "ok=1; Path=/" {
+			t.Errorf("expected only the safe cookie to be reflected, got %v", got)
+		}
+	})
+
+	t.Log("TestReflectCookies passed")
+}
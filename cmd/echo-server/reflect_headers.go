@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// reflectedHeaderPrefix is prepended to the name of every reflected header.
+const reflectedHeaderPrefix = "X-Echo-"
+
+// hopByHopHeaders are connection-specific headers that must not be
+// forwarded, and are therefore never reflected.
+var hopByHopHeaders = map[string]bool{
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Proxy-Authenticate":  true,
+	"Proxy-Authorization": true,
+	"Proxy-Connection":    true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+}
+
+// reflectHeadersHandler handles GET /reflect-headers. The request headers
+// listed in the "headers" query parameter (comma-separated or repeated) are
+// copied to the response with an X-Echo- prefix, e.g. X-Foo becomes
+// X-Echo-Foo and Accept becomes X-Echo-Accept.
+func reflectHeadersHandler(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	for _, v := range r.URL.Query()["headers"] {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		if !httpguts.ValidHeaderFieldName(name) {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid header name %q", name))
+			return
+		}
+	}
+
+	reflected := map[string]interface{}{}
+	skipped := []string{}
+
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		values := r.Header.Values(name)
+
+		if hopByHopHeaders[name] || len(values) == 0 {
+			skipped = append(skipped, name)
+			continue
+		}
+
+		reflectedName := reflectedHeaderName(name)
+		for _, value := range values {
+			w.Header().Add(reflectedName, value)
+		}
+		reflected[reflectedName] = strings.Join(values, ", ")
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"reflected": reflected,
+		"skipped":   skipped,
+	})
+}
+
+// reflectedHeaderName returns the response header name used to reflect the
+// request header name.
+func reflectedHeaderName(name string) string {
+	if len(name) > 2 && strings.EqualFold(name[:2], "X-") {
+		name = name[2:]
+	}
+	return http.CanonicalHeaderKey(reflectedHeaderPrefix + name)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// TestReflectHeaders verifies selected request headers are reflected with a prefix
+func TestReflectHeaders(t *testing.T) {
+	req, err := http.NewRequest("GET", httpBaseURL+"/reflect-headers?headers=X-Foo,Accept&headers=Connection,X-Missing", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("X-Foo", "bar")
+	req.Header.Set("Accept", "text/plain")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	if v := resp.Header.Get("X-Echo-Foo"); v != "bar" {
+		t.Errorf("expected X-Echo-Foo 'bar', got %q", v)
+	}
+
+	if v := resp.Header.Get("X-Echo-Accept"); v != "text/plain" {
+		t.Errorf("expected X-Echo-Accept 'text/plain', got %q", v)
+	}
+
+	if v := resp.Header.Get("X-Echo-Connection"); v != "" {
+		t.Errorf("expected hop-by-hop header not to be reflected, got %q", v)
+	}
+
+	var result struct {
+		Skipped []string `json:"skipped"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if len(result.Skipped) != 2 {
+		t.Errorf("expected 2 skipped headers, got %v", result.Skipped)
+	}
+
+	t.Run("Invalid header name", func(t *testing.T) {
+		resp, err := http.Get(httpBaseURL + "/reflect-headers?headers=Bad%20Name")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Log("TestReflectHeaders passed")
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// resetPartialBody is written before the connection is reset, so the client
+// sees the reset part way through the response body.
+const resetPartialBody = "partial response before connection reset"
+
+// resetRate returns the fraction of echo requests whose connection should be
+// reset, as configured by RESET_RATE. It defaults to zero.
+func resetRate() (float64, error) {
+	return envFraction("RESET_RATE")
+}
+
+// shouldReset reports whether the request's connection should be reset,
+// either because it asks for it with ?reset=true or at random according to
+// RESET_RATE.
+func shouldReset(req *http.Request) bool {
+	if reset, _ := strconv.ParseBool(req.URL.Query().Get("reset")); reset {
+		return true
+	}
+
+	rate, _ := resetRate() // Validated at startup by validateConfig
+	return rate > 0 && rand.Float64() < rate
+}
+
+// injectReset abruptly aborts the response if shouldReset says so. It reports
+// whether the response was aborted, in which case the caller must not write
+// anything else.
+//
+// HTTP/1.x connections are hijacked, sent the status line, headers and part
+// of the body, and closed with SO_LINGER set to zero so the kernel sends a TCP
+// RST instead of a FIN. HTTP/2 connections cannot be hijacked, so only the
+// stream is reset with RST_STREAM.
+func injectReset(wr http.ResponseWriter, req *http.Request) bool {
+	if !shouldReset(req) {
+		return false
+	}
+
+	fmt.Printf("%s | reset | resetting connection for %s %s\n", req.RemoteAddr, req.Method, req.URL)
+
+	conn, buf, err := http.NewResponseController(wr).Hijack()
+	if err != nil {
+		// Resets the HTTP/2 stream; recoverMiddleware lets it through.
+		panic(http.ErrAbortHandler)
+	}
+
+	fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Length: %d\r\n\r\n%s",
+		len(resetPartialBody)*2, resetPartialBody)
+	buf.Flush() // nolint:errcheck
+
+	if tcp, ok := underlyingTCPConn(conn); ok {
+		tcp.SetLinger(0) // nolint:errcheck
+	}
+	conn.Close()
+	return true
+}
+
+// underlyingTCPConn unwraps TLS and PROXY protocol connections to find the
+// TCP connection beneath.
+func underlyingTCPConn(conn net.Conn) (*net.TCPConn, bool) {
+	for {
+		switch c := conn.(type) {
+		case *net.TCPConn:
+			return c, true
+		case interface{ NetConn() net.Conn }:
+			conn = c.NetConn()
+		case interface{ Raw() net.Conn }:
+			conn = c.Raw()
+		default:
+			return nil, false
+		}
+	}
+}
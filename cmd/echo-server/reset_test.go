@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+// TestConnectionReset verifies the client sees a connection error when a reset is requested
+func TestConnectionReset(t *testing.T) {
+	// A fresh transport so the reset does not affect other tests' connections
+	client := &http.Client{Transport: &http.Transport{}}
+
+	resp, err := client.Get(httpBaseURL + "/?reset=true")
+	if err == nil {
+		// The reset may race the headers; either way the body must fail
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	if err == nil {
+		t.Fatal("expected a connection error")
+	}
+	t.Logf("client saw: %v", err)
+
+	t.Log("TestConnectionReset passed")
+}
+
+// TestResetRateValidation verifies that out-of-range reset rates are rejected
+func TestResetRateValidation(t *testing.T) {
+	for _, v := range []string{"-0.1", "1.5", "often"} {
+		t.Setenv("RESET_RATE", v)
+		if _, err := resetRate(); err == nil {
+			t.Errorf("expected error for RESET_RATE=%q", v)
+		}
+	}
+
+	t.Log("TestResetRateValidation passed")
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// respondWithContentTypeHeader sets the Content-Type of a response taken from
+// the RESPOND_WITH_HEADER request header.
+const respondWithContentTypeHeader = "X-Echo-Respond-Content-Type"
+
+// maxRespondWithLength caps the length of a response taken from a request
+// header.
+const maxRespondWithLength = 8192
+
+// respondWithHeader returns the name of the request header whose value is
+// sent back as the response body, as configured by RESPOND_WITH_HEADER
+// (e.g. "X-Echo-Respond-With"). It returns "" when unset.
+func respondWithHeader() (string, error) {
+	v := os.Getenv("RESPOND_WITH_HEADER")
+	if v == "" {
+		return "", nil
+	}
+	if !httpguts.ValidHeaderFieldName(v) {
+		return "", fmt.Errorf("RESPOND_WITH_HEADER: %q is not a valid header name", v)
+	}
+	return http.CanonicalHeaderKey(v), nil
+}
+
+// serveRespondWith answers with the value of the RESPOND_WITH_HEADER request
+// header as the body instead of the echo, labeled with the media type in
+// X-Echo-Respond-Content-Type or else contentType. It reports whether the
+// request carried the header and was answered.
+func serveRespondWith(wr http.ResponseWriter, req *http.Request, code int, contentType string) bool {
+	name, _ := respondWithHeader() // Validated at startup by validateConfig
+	if name == "" {
+		return false
+	}
+	values, ok := req.Header[name]
+	if !ok {
+		return false
+	}
+
+	body := values[0]
+	if len(body) > maxRespondWithLength {
+		writeError(wr, http.StatusBadRequest, fmt.Sprintf("%s exceeds the maximum of %d bytes", name, maxRespondWithLength))
+		return true
+	}
+
+	if v := req.Header.Get(respondWithContentTypeHeader); v != "" {
+		if !isPlausibleMediaType(v) {
+			writeError(wr, http.StatusBadRequest, fmt.Sprintf("Invalid %s %q", respondWithContentTypeHeader, v))
+			return true
+		}
+		contentType = v
+	}
+
+	wr.Header().Set("Content-Type", contentType)
+	wr.WriteHeader(code)
+	fmt.Fprint(wr, body)
+	return true
+}
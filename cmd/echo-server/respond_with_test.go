@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestRespondWith verifies the configured request header's value is sent back verbatim as the body
+func TestRespondWith(t *testing.T) {
+	t.Setenv("RESPOND_WITH_HEADER", "X-Echo-Respond-With")
+
+	do := func(t *testing.T, headers map[string]string) (*http.Response, string) {
+		t.Helper()
+
+		req, err := http.NewRequest("GET", httpBaseURL+"/canned", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+		return resp, string(body)
+	}
+
+	t.Run("Verbatim body", func(t *testing.T) {
+		resp, body := do(t, map[string]string{
+			"X-Echo-Respond-With":         `{"ok":true}`,
+			"X-Echo-Respond-Content-Type": "application/json",
+		})
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+		if body != `{"ok":true}` {
+			t.Errorf("expected body %q, got %q", `{"ok":true}`, body)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected Content-Type application/json, got %q", ct)
+		}
+	})
+
+	t.Run("Without the header", func(t *testing.T) {
+		_, body := do(t, nil)
+		if !strings.Contains(body, "GET /canned") {
+			t.Errorf("expected the usual echo, got %q", body)
+		}
+	})
+
+	t.Run("Too long", func(t *testing.T) {
+		resp, _ := do(t, map[string]string{"X-Echo-Respond-With": strings.Repeat("a", maxRespondWithLength+1)})
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Invalid content type", func(t *testing.T) {
+		resp, _ := do(t, map[string]string{
+			"X-Echo-Respond-With":         "hello",
+			"X-Echo-Respond-Content-Type": "not a type",
+		})
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Log("TestRespondWith passed")
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// pathRewrite is a rule rewriting request paths matching pattern to
+// replacement, which may refer to submatches as $1 or ${name}.
+type pathRewrite struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// pathRewriteCache holds the compiled PATH_REWRITE, so the expression is
+// compiled once rather than for every request.
+var pathRewriteCache struct {
+	mu     sync.Mutex
+	source string
+	rule   *pathRewrite
+}
+
+// rewrittenFromKey is the request context key holding the original request
+// URI of a rewritten request.
+type rewrittenFromKey struct{}
+
+// pathRewriteRule returns the rewrite rule configured by PATH_REWRITE as
+// "pattern=replacement", e.g. "^/old/(.*)$=/new/$1". The rule is split at the
+// last "=", so the pattern may contain "=" but the replacement may not. It
+// returns nil when unset.
+func pathRewriteRule() (*pathRewrite, error) {
+	v := os.Getenv("PATH_REWRITE")
+	if v == "" {
+		return nil, nil
+	}
+
+	pathRewriteCache.mu.Lock()
+	defer pathRewriteCache.mu.Unlock()
+
+	if pathRewriteCache.rule != nil && pathRewriteCache.source == v {
+		return pathRewriteCache.rule, nil
+	}
+
+	i := strings.LastIndex(v, "=")
+	if i <= 0 {
+		return nil, fmt.Errorf("PATH_REWRITE: %q must have the form pattern=replacement", v)
+	}
+	re, err := regexp.Compile(v[:i])
+	if err != nil {
+		return nil, fmt.Errorf("PATH_REWRITE: %v", err)
+	}
+
+	rule := &pathRewrite{pattern: re, replacement: v[i+1:]}
+	pathRewriteCache.source, pathRewriteCache.rule = v, rule
+	return rule, nil
+}
+
+// rewritePath applies PATH_REWRITE to the request path, as an ingress that
+// rewrites paths would. It returns req unchanged when there is no rule or
+// the path does not match, and otherwise a copy with the rewritten path that
+// remembers the original request URI for the echo.
+func rewritePath(req *http.Request) *http.Request {
+	rule, _ := pathRewriteRule() // Validated at startup by validateConfig
+	if rule == nil || !rule.pattern.MatchString(req.URL.Path) {
+		return req
+	}
+
+	rewritten := rule.pattern.ReplaceAllString(req.URL.Path, rule.replacement)
+	fmt.Printf("%s | rewrite | %s -> %s\n", req.RemoteAddr, req.URL.Path, rewritten)
+
+	original := req.RequestURI
+	req = req.WithContext(context.WithValue(req.Context(), rewrittenFromKey{}, original))
+	u := *req.URL
+	u.Path, u.RawPath = rewritten, ""
+	req.URL = &u
+	return req
+}
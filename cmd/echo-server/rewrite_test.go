@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestPathRewrite verifies PATH_REWRITE rewrites matching paths and the echo reports both paths
+func TestPathRewrite(t *testing.T) {
+	t.Setenv("PATH_REWRITE", "^/old/(.*)$=/new/$1")
+
+	get := func(t *testing.T, path string) string {
+		t.Helper()
+
+		resp, err := http.Get(httpBaseURL + path)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+		return string(body)
+	}
+
+	t.Run("Matching path", func(t *testing.T) {
+		body := get(t, "/old/a/b?x=1")
+
+		for _, want := range []string{"GET /new/a/b?x=1 HTTP/1.1\n", "Rewritten from: /old/a/b?x=1\n"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected echo to contain %q, got: %s", want, body)
+			}
+		}
+	})
+
+	t.Run("Other path", func(t *testing.T) {
+		body := get(t, "/other")
+
+		if !strings.Contains(body, "GET /other HTTP/1.1\n") || strings.Contains(body, "Rewritten from") {
+			t.Errorf("expected the path left alone, got: %s", body)
+		}
+	})
+
+	t.Run("Invalid rule", func(t *testing.T) {
+		for _, rule := range []string{"no-separator", "([=/x"} {
+			t.Setenv("PATH_REWRITE", rule)
+			if _, err := pathRewriteRule(); err == nil {
+				t.Errorf("expected error for rule %q", rule)
+			}
+		}
+	})
+
+	t.Log("TestPathRewrite passed")
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// runtimeOverrides holds the settings changed at runtime through
+// /admin/config. A nil field leaves the setting to the environment.
+//
+// Overrides are never modified in place: updates store a new copy, so the
+// request path can read them without locking.
+type runtimeOverrides struct {
+	delay              *time.Duration
+	chaosErrorRate     *float64
+	maintenance        bool
+	sendServerHostname *bool
+}
+
+var (
+	// overrides holds the current runtime overrides.
+	overrides atomic.Pointer[runtimeOverrides]
+
+	// overridesMu serializes updates to overrides.
+	overridesMu sync.Mutex
+)
+
+func init() {
+	overrides.Store(&runtimeOverrides{})
+}
+
+// currentOverrides returns the runtime overrides in effect.
+func currentOverrides() *runtimeOverrides {
+	return overrides.Load()
+}
+
+// runtimeConfig is the JSON shape of the settings /admin/config reports and
+// accepts. In updates, omitted fields are left unchanged.
+type runtimeConfig struct {
+	Delay              *string  `json:"delay"`
+	ChaosErrorRate     *float64 `json:"chaos_error_rate"`
+	Maintenance        *bool    `json:"maintenance"`
+	SendServerHostname *bool    `json:"send_server_hostname"`
+}
+
+// effectiveRuntimeConfig returns the settings currently in effect, from the
+// runtime overrides or else the environment.
+func effectiveRuntimeConfig() runtimeConfig {
+	o := currentOverrides()
+
+	delayCfg, _ := loadDelayConfig() // Validated at startup by validateConfig
+	delay := delayCfg.base.String()
+
+	rate := effectiveChaosErrorRate()
+	sendHostname := defaultSendServerHostname()
+
+	return runtimeConfig{
+		Delay:              &delay,
+		ChaosErrorRate:     &rate,
+		Maintenance:        &o.maintenance,
+		SendServerHostname: &sendHostname,
+	}
+}
+
+// effectiveChaosErrorRate returns the chaos error rate in effect.
+func effectiveChaosErrorRate() float64 {
+	if rate := currentOverrides().chaosErrorRate; rate != nil {
+		return *rate
+	}
+	rate, _ := chaosErrorRate() // Validated at startup by validateConfig
+	return rate
+}
+
+// defaultSendServerHostname reports whether echoes include the server
+// hostname when the request does not say otherwise with an
+// X-Send-Server-Hostname header.
+func defaultSendServerHostname() bool {
+	if v := currentOverrides().sendServerHostname; v != nil {
+		return *v
+	}
+	return !strings.EqualFold(os.Getenv("SEND_SERVER_HOSTNAME"), "false")
+}
+
+// adminConfigHandler handles GET and POST /admin/config. POST applies the
+// JSON settings in the body without a restart; both return the settings in
+// effect afterwards.
+func adminConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		var update runtimeConfig
+
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&update); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid config: %v", err))
+			return
+		}
+
+		if err := applyRuntimeConfig(update); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		fmt.Printf("%s | admin | runtime config updated\n", r.RemoteAddr)
+	}
+
+	writeJSON(w, http.StatusOK, effectiveRuntimeConfig())
+}
+
+// applyRuntimeConfig validates update and stores its fields as runtime
+// overrides. Nothing is changed unless every field is valid.
+func applyRuntimeConfig(update runtimeConfig) error {
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+
+	next := *currentOverrides()
+
+	if update.Delay != nil {
+		delay, err := time.ParseDuration(*update.Delay)
+		if err != nil || delay < 0 {
+			return fmt.Errorf("delay: %q is not a valid non-negative duration", *update.Delay)
+		}
+		next.delay = &delay
+	}
+
+	if update.ChaosErrorRate != nil {
+		rate := *update.ChaosErrorRate
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("chaos_error_rate: %v must be a number between 0 and 1", rate)
+		}
+		next.chaosErrorRate = &rate
+	}
+
+	if update.Maintenance != nil {
+		next.maintenance = *update.Maintenance
+	}
+
+	if update.SendServerHostname != nil {
+		sendHostname := *update.SendServerHostname
+		next.sendServerHostname = &sendHostname
+	}
+
+	overrides.Store(&next)
+	return nil
+}
+
+// rejectInMaintenance answers echo requests with 503 while maintenance mode
+// is switched on through /admin/config. It reports whether the request was
+// rejected.
+func rejectInMaintenance(wr http.ResponseWriter) bool {
+	if !currentOverrides().maintenance {
+		return false
+	}
+
+	wr.Header().Set("Retry-After", "30")
+	writeError(wr, http.StatusServiceUnavailable, "Server is in maintenance mode")
+	return true
+}
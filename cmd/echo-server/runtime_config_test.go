@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// postAdminConfig posts body to /admin/config with the test admin token.
+func postAdminConfig(t *testing.T, body string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req, err := http.NewRequest("POST", httpBaseURL+"/admin/config", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result) // nolint:errcheck
+	return resp.StatusCode, result
+}
+
+// TestAdminConfig verifies runtime config changes through /admin/config take effect
+func TestAdminConfig(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "secret")
+
+	// Restore the environment-driven settings for the other tests
+	t.Cleanup(func() { overrides.Store(&runtimeOverrides{}) })
+
+	t.Run("Delay", func(t *testing.T) {
+		code, result := postAdminConfig(t, `{"delay":"300ms"}`)
+		if code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %v", code, result)
+		}
+		if result["delay"] != "300ms" {
+			t.Errorf("expected effective delay 300ms, got %v", result["delay"])
+		}
+
+		start := time.Now()
+		resp, err := http.Get(httpBaseURL + "/delayed")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+			t.Errorf("expected the echo to be delayed by 300ms, took %s", elapsed)
+		}
+
+		postAdminConfig(t, `{"delay":"0s"}`)
+	})
+
+	t.Run("Maintenance", func(t *testing.T) {
+		postAdminConfig(t, `{"maintenance":true}`)
+
+		resp, err := http.Get(httpBaseURL + "/")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", resp.StatusCode)
+		}
+
+		postAdminConfig(t, `{"maintenance":false}`)
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		for _, body := range []string{
+			`{"delay":"soon"}`,
+			`{"chaos_error_rate":2}`,
+			`{"maintenance":true,"chaos_error_rate":-1}`,
+			`{"unknown":true}`,
+		} {
+			if code, _ := postAdminConfig(t, body); code != http.StatusBadRequest {
+				t.Errorf("expected status 400 for %s, got %d", body, code)
+			}
+		}
+
+		// Rejected updates change nothing
+		if currentOverrides().maintenance {
+			t.Error("expected maintenance mode to stay off")
+		}
+	})
+
+	t.Log("TestAdminConfig passed")
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	echo "http-echo/cmd/echo-server/grpc/generated"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// selfTestTimeout bounds each loopback call made by the self-test.
+const selfTestTimeout = 5 * time.Second
+
+// runSelfTest checks that the server works in its environment before it
+// starts serving: that the HTTP and gRPC ports can be bound, and that an
+// echo request and a gRPC Echo call succeed over loopback. Each check is
+// logged with its result, and the failures are returned together.
+func runSelfTest(port, grpcPort string) error {
+	checks := []struct {
+		name string
+		run  func() error
+	}{
+		{"bind HTTP port " + port, func() error { return checkBind(port) }},
+		{"bind gRPC port " + grpcPort, func() error { return checkBind(grpcPort) }},
+		{"HTTP loopback echo", checkHTTPEcho},
+		{"gRPC loopback echo", checkGRPCEcho},
+	}
+
+	var errs []error
+	for _, check := range checks {
+		if err := check.run(); err != nil {
+			fmt.Printf("Self-test: %s: FAIL (%v)\n", check.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", check.name, err))
+			continue
+		}
+		fmt.Printf("Self-test: %s: PASS\n", check.name)
+	}
+	return errors.Join(errs...)
+}
+
+// checkBind reports whether the TCP port can be listened on.
+func checkBind(port string) error {
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+	return lis.Close()
+}
+
+// checkHTTPEcho serves the router on a loopback port and checks that a
+// request to it is echoed.
+func checkHTTPEcho() error {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: createRouter(), ConnContext: connContext}
+	go server.Serve(lis) // nolint:errcheck
+	defer server.Close()
+
+	client := &http.Client{Timeout: selfTestTimeout}
+	resp, err := client.Get("http://" + lis.Addr().String() + "/self-test")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if !strings.Contains(string(body), "GET /self-test") {
+		return errors.New("response is not an echo of the request")
+	}
+	return nil
+}
+
+// checkGRPCEcho serves the gRPC echo service on a loopback port and checks
+// that an Echo call to it succeeds. The GRPC_WARMUP period does not apply.
+func checkGRPCEcho() error {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	s := grpc.NewServer()
+	echo.RegisterEchoServer(s, &grpcEchoServer{})
+	go s.Serve(lis) // nolint:errcheck
+	defer s.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), selfTestTimeout)
+	defer cancel()
+
+	resp, err := echo.NewEchoClient(conn).Echo(ctx, &echo.EchoRequest{Message: "self-test"})
+	if err != nil {
+		return err
+	}
+	if resp.GetMessage() != "self-test" {
+		return fmt.Errorf("unexpected message %q", resp.GetMessage())
+	}
+	return nil
+}
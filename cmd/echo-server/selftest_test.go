@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+// TestSelfTest verifies the startup self-test passes in a working environment and reports ports that cannot be bound
+func TestSelfTest(t *testing.T) {
+	t.Run("Passes", func(t *testing.T) {
+		if err := runSelfTest("0", "0"); err != nil {
+			t.Errorf("expected the self-test to pass, got: %v", err)
+		}
+	})
+
+	t.Run("Port in use", func(t *testing.T) {
+		lis, err := net.Listen("tcp", ":0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		defer lis.Close()
+
+		_, port, _ := net.SplitHostPort(lis.Addr().String())
+
+		err = runSelfTest(port, "0")
+		if err == nil {
+			t.Fatal("expected the self-test to fail for a port in use")
+		}
+		if !strings.Contains(err.Error(), "bind HTTP port "+port) {
+			t.Errorf("expected the failure to name the HTTP port check, got: %v", err)
+		}
+	})
+
+	t.Log("TestSelfTest passed")
+}
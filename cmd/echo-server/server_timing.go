@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// serverTimingRequestHeader lets clients ask for a synthetic Server-Timing
+// response header.
+const serverTimingRequestHeader = "X-Echo-Server-Timing"
+
+// parseServerTiming validates a Server-Timing value such as
+// "db;dur=53, app;dur=47;desc=render" and returns it in canonical form.
+// Every metric needs a token name and may have a non-negative "dur" in
+// milliseconds and a "desc", either a token or a quoted string, which may
+// itself contain commas and semicolons.
+func parseServerTiming(v string) (string, error) {
+	var metrics []string
+
+	for _, metric := range splitUnquoted(v, ',') {
+		parts := splitUnquoted(metric, ';')
+
+		name := strings.TrimSpace(parts[0])
+		if !isToken(name) {
+			return "", fmt.Errorf("invalid Server-Timing metric name %q", name)
+		}
+
+		canonical := name
+		for _, param := range parts[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			key = strings.ToLower(strings.TrimSpace(key))
+			value = strings.TrimSpace(value)
+
+			switch key {
+			case "dur":
+				dur, err := strconv.ParseFloat(value, 64)
+				if err != nil || dur < 0 {
+					return "", fmt.Errorf("invalid Server-Timing duration %q for %s", value, name)
+				}
+			case "desc":
+				if !isToken(value) && !isQuotedString(value) {
+					return "", fmt.Errorf("invalid Server-Timing description %q for %s", value, name)
+				}
+			default:
+				return "", fmt.Errorf("unknown Server-Timing parameter %q for %s", key, name)
+			}
+
+			canonical += ";" + key + "=" + value
+		}
+
+		metrics = append(metrics, canonical)
+	}
+
+	return strings.Join(metrics, ", "), nil
+}
+
+// splitUnquoted splits s around each sep that is not inside an HTTP
+// quoted-string.
+func splitUnquoted(s string, sep byte) []string {
+	var parts []string
+	start, quoted := 0, false
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quoted && c == '\\':
+			i++ // Skip the escaped character
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// isToken reports whether s is a non-empty HTTP token.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !httpguts.IsTokenRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isQuotedString reports whether s is an HTTP quoted-string without control
+// characters.
+func isQuotedString(s string) bool {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return false
+	}
+
+	inner := s[1 : len(s)-1]
+	for i := 0; i < len(inner); i++ {
+		switch c := inner[i]; {
+		case c == '\\':
+			i++ // The escaped character may be anything but a control character
+			if i == len(inner) || inner[i] < ' ' || inner[i] == 0x7f {
+				return false
+			}
+		case c == '"' || c < ' ' || c == 0x7f:
+			return false
+		}
+	}
+	return true
+}
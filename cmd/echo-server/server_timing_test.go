@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+// TestServerTiming verifies X-Echo-Server-Timing is validated and returned as Server-Timing
+func TestServerTiming(t *testing.T) {
+	tests := []struct {
+		name       string
+		timing     string
+		wantStatus int
+		wantHeader string
+	}{
+		{"Durations", "db;dur=53, app;dur=47.5", http.StatusOK, "db;dur=53, app;dur=47.5"},
+		{"Descriptions", `cache;desc="Cache Read";dur=23.2,miss`, http.StatusOK, `cache;desc="Cache Read";dur=23.2, miss`},
+		{"Negative duration", "db;dur=-1", http.StatusBadRequest, ""},
+		{"Invalid name", "d b;dur=1", http.StatusBadRequest, ""},
+		{"Unknown parameter", "db;time=1", http.StatusBadRequest, ""},
+		{"Unquoted description", "db;desc=two words", http.StatusBadRequest, ""},
+		{"Quoted separators", `db;desc="a, b; c";dur=1,app`, http.StatusOK, `db;desc="a, b; c";dur=1, app`},
+		{"Escaped quote", `db;desc="say \"hi, there\""`, http.StatusOK, `db;desc="say \"hi, there\""`},
+		{"Unterminated quote", `db;desc="a, b`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", httpBaseURL+"/timing", nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			req.Header.Set(serverTimingRequestHeader, tt.timing)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if got := resp.Header.Get("Server-Timing"); got != tt.wantHeader {
+				t.Errorf("expected Server-Timing %q, got %q", tt.wantHeader, got)
+			}
+		})
+	}
+
+	t.Log("TestServerTiming passed")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// serveManualResponse writes an HTTP/1.1 response with the given status
+// code, the headers of wr and body by hand, on the hijacked connection, for
+// what Go's server cannot do:
+//
+//   - With a delay, it pauses that long before each header line, which
+//     stresses the client's header read timeout independently of its body
+//     timeout (SLOW_HEADERS).
+//   - Header names found in headerCase, keyed by their canonical form, are
+//     written with the exact casing given there (RAW_HEADER_CASE).
+//
+// The body is left out for HEAD requests. The connection is closed once the
+// response is written or the client goes away, which is detected by the
+// failed flush of a header line, as the request context is not canceled on
+// hijacked connections. It reports whether the response was written; it
+// returns false when the connection cannot be hijacked, e.g. for HTTP/2, in
+// which case the caller must write the response normally.
+func serveManualResponse(wr http.ResponseWriter, req *http.Request, code int, body []byte, delay time.Duration, headerCase map[string]string) bool {
+	conn, buf, err := http.NewResponseController(wr).Hijack()
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	header := wr.Header().Clone()
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	header.Set("Connection", "close")
+
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if delay > 0 {
+		fmt.Printf("%s | slow headers | sending %d header(s) %s apart\n", req.RemoteAddr, len(names), delay)
+	}
+
+	fmt.Fprintf(buf, "HTTP/1.1 %d %s\r\n", code, http.StatusText(code))
+	if err := buf.Flush(); err != nil {
+		return true
+	}
+
+	for _, name := range names {
+		raw, ok := headerCase[name]
+		if !ok {
+			raw = name
+		}
+
+		for _, value := range header[name] {
+			time.Sleep(delay)
+
+			fmt.Fprintf(buf, "%s: %s\r\n", raw, value)
+			if delay > 0 {
+				if err := buf.Flush(); err != nil {
+					fmt.Printf("%s | slow headers | client went away: %v\n", req.RemoteAddr, err)
+					return true
+				}
+			}
+		}
+	}
+
+	buf.WriteString("\r\n") // nolint:errcheck
+	if req.Method != http.MethodHead {
+		buf.Write(body) // nolint:errcheck
+	}
+	buf.Flush() // nolint:errcheck
+	return true
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSlowHeaders verifies SLOW_HEADERS delays each response header line
+func TestSlowHeaders(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	t.Setenv("SLOW_HEADERS", delay.String())
+
+	start := time.Now()
+	resp, err := http.Get(httpBaseURL + "/slow")
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+	headerPhase := time.Since(start)
+
+	// At least Content-Type, Content-Length, Date and Connection are sent
+	if headerPhase < 4*delay {
+		t.Errorf("expected the header phase to take at least %s, took %s", 4*delay, headerPhase)
+	}
+	t.Logf("header phase took %s", headerPhase)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read response body: %v", err)
+	}
+	if !strings.Contains(string(body), "GET /slow HTTP/1.1") {
+		t.Errorf("expected the request to be echoed, got: %s", body)
+	}
+
+	t.Log("TestSlowHeaders passed")
+}
+
+// TestSlowHeadersHead verifies SLOW_HEADERS responses to HEAD requests have no body
+func TestSlowHeadersHead(t *testing.T) {
+	t.Setenv("SLOW_HEADERS", "1ms")
+
+	conn, err := net.DialTimeout("tcp", "localhost:"+testHTTPPort, 3*time.Second)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(3 * time.Second)) // nolint:errcheck
+
+	io.WriteString(conn, "HEAD /slow-head HTTP/1.1\r\nHost: localhost\r\n\r\n") // nolint:errcheck
+
+	// The server closes the connection after the response, so read it all
+	raw, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if !strings.HasPrefix(string(raw), "HTTP/1.1 200 OK\r\n") {
+		t.Errorf("expected status 200, got: %s", raw)
+	}
+	if !strings.HasSuffix(string(raw), "\r\n\r\n") {
+		t.Errorf("expected nothing after the headers, got: %s", raw)
+	}
+
+	t.Log("TestSlowHeadersHead passed")
+}
+
+// TestSlowHeadersClientGone verifies SLOW_HEADERS stops sending headers once the client disconnects
+func TestSlowHeadersClientGone(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	t.Setenv("SLOW_HEADERS", delay.String())
+
+	out := captureStdout(t, func() {
+		conn, err := net.DialTimeout("tcp", "localhost:"+testHTTPPort, 3*time.Second)
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		conn.SetDeadline(time.Now().Add(3 * time.Second)) // nolint:errcheck
+
+		io.WriteString(conn, "GET /slow-gone HTTP/1.1\r\nHost: localhost\r\n\r\n") // nolint:errcheck
+		if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+			t.Fatalf("failed to read status line: %v", err)
+		}
+		conn.Close()
+
+		// Give the server time to notice while it still has headers to send
+		time.Sleep(10 * delay)
+	})
+
+	if !strings.Contains(out, "| slow headers | client went away") {
+		t.Errorf("expected the disconnect to be noticed, got: %s", out)
+	}
+
+	t.Log("TestSlowHeadersClientGone passed")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+)
+
+// clientCounter counts requests per client IP, keeping only the most
+// recently seen clients so memory stays bounded.
+type clientCounter struct {
+	mu      sync.Mutex
+	recent  *list.List // of *clientCount, most recently seen first
+	clients map[string]*list.Element
+}
+
+// clientCount is the request count of one client IP.
+type clientCount struct {
+	ip    string
+	count int
+}
+
+// clientStats holds the per-client request counts kept when CLIENT_STATS is
+// set.
+var clientStats = &clientCounter{recent: list.New(), clients: map[string]*list.Element{}}
+
+// add counts a request from ip, evicting the least recently seen clients
+// beyond limit.
+func (c *clientCounter) add(ip string, limit int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.clients[ip]; ok {
+		e.Value.(*clientCount).count++
+		c.recent.MoveToFront(e)
+		return
+	}
+
+	c.clients[ip] = c.recent.PushFront(&clientCount{ip: ip, count: 1})
+	for c.recent.Len() > limit {
+		oldest := c.recent.Back()
+		delete(c.clients, oldest.Value.(*clientCount).ip)
+		c.recent.Remove(oldest)
+	}
+}
+
+// counts returns the request count of every tracked client, keyed by IP.
+func (c *clientCounter) counts() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[string]int, len(c.clients))
+	for ip, e := range c.clients {
+		counts[ip] = e.Value.(*clientCount).count
+	}
+	return counts
+}
+
+// countClientsMiddleware counts every request per client IP, as reported by
+// accountingClientIP, when CLIENT_STATS is set to the number of clients to
+// track.
+func countClientsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := envInt("CLIENT_STATS") // Validated at startup by validateConfig
+		if limit > 0 {
+			clientStats.add(accountingClientIP(r), limit)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statsHandler handles GET /stats, returning the request count of each
+// tracked client IP as a JSON object.
+func statsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, clientStats.counts())
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"container/list"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// TestClientStats verifies /stats reports request counts per client IP
+func TestClientStats(t *testing.T) {
+	t.Setenv("CLIENT_STATS", "100")
+	t.Setenv("ADMIN_TOKEN", "secret")
+
+	// The test client is trusted to name the client in X-Forwarded-For
+	t.Setenv("TRUST_PROXY", "127.0.0.1,::1")
+
+	const clientAddr = "203.0.113.7"
+
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest("GET", httpBaseURL+"/counted", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("X-Forwarded-For", clientAddr)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	stats := func(t *testing.T, token string) (*http.Response, map[string]int) {
+		t.Helper()
+
+		req, err := http.NewRequest("GET", httpBaseURL+"/stats", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var counts map[string]int
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&counts); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return resp, counts
+	}
+
+	t.Run("Counts", func(t *testing.T) {
+		resp, counts := stats(t, "secret")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		if counts[clientAddr] != 3 {
+			t.Errorf("expected 3 requests from %s, got %d (%v)", clientAddr, counts[clientAddr], counts)
+		}
+	})
+
+	for _, trustProxy := range []string{"false", ""} {
+		t.Run("Untrusted proxy "+strconv.Quote(trustProxy), func(t *testing.T) {
+			t.Setenv("TRUST_PROXY", trustProxy)
+
+			const spoofed = "198.51.100.9"
+			req, err := http.NewRequest("GET", httpBaseURL+"/counted", nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			req.Header.Set("X-Forwarded-For", spoofed)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			resp.Body.Close()
+
+			// The request is counted for the peer address instead
+			if _, counts := stats(t, "secret"); counts[spoofed] != 0 {
+				t.Errorf("expected X-Forwarded-For to be ignored, got %v", counts)
+			}
+		})
+	}
+
+	t.Run("Requires admin token", func(t *testing.T) {
+		if resp, _ := stats(t, "wrong"); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Evicts least recently seen", func(t *testing.T) {
+		c := &clientCounter{recent: list.New(), clients: map[string]*list.Element{}}
+		c.add("a", 2)
+		c.add("b", 2)
+		c.add("a", 2)
+		c.add("c", 2)
+
+		counts := c.counts()
+		if _, ok := counts["b"]; ok || counts["a"] != 2 || counts["c"] != 1 {
+			t.Errorf("expected b to be evicted, got %v", counts)
+		}
+	})
+
+	t.Log("TestClientStats passed")
+}
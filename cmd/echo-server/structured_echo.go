@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// echoFormatTypes maps the structured echo formats to their media types.
+var echoFormatTypes = map[string]string{
+	"json":    "application/json",
+	"yaml":    "application/yaml",
+	"postman": "application/json",
+}
+
+// echoDocument is the request as reported by structured (JSON and YAML)
+// echoes.
+type echoDocument struct {
+	Method   string              `json:"method" yaml:"method"`
+	URL      string              `json:"url" yaml:"url"`
+	Proto    string              `json:"proto" yaml:"proto"`
+	Host     string              `json:"host" yaml:"host"`
+	Headers  map[string][]string `json:"headers" yaml:"headers"`
+	Body     string              `json:"body" yaml:"body"`
+	Nonce    string              `json:"nonce,omitempty" yaml:"nonce,omitempty"`
+	Hostname string              `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+}
+
+// echoFormat returns the structured format the request asks its echo to be
+// in: "json", "yaml" or "postman" from the "format" query parameter, or
+// "yaml" when the Accept header prefers application/yaml over text/plain. It
+// returns "" for the usual plain-text echo, and an error for an unknown
+// format.
+func echoFormat(req *http.Request) (string, error) {
+	if v, ok := req.URL.Query()["format"]; ok {
+		switch v[0] {
+		case "text":
+			return "", nil
+		case "json", "yaml", "postman":
+			return v[0], nil
+		default:
+			return "", fmt.Errorf("Invalid format %q (want text, json, yaml or postman)", v[0])
+		}
+	}
+
+	if negotiateMediaType(req.Header.Get("Accept"), []string{"text/plain", "application/yaml"}) == "application/yaml" {
+		return "yaml", nil
+	}
+	return "", nil
+}
+
+// newEchoDocument builds the structured echo of req, decoding the body as the
+// plain-text echo does.
+func newEchoDocument(req *http.Request, nonce string, sendServerHostname bool) echoDocument {
+	raw, _ := io.ReadAll(req.Body)
+	body, _ := decodeRequestBody(req, raw)
+
+	doc := echoDocument{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Proto:   req.Proto,
+		Host:    req.Host,
+		Headers: req.Header,
+		Body:    string(body),
+		Nonce:   nonce,
+	}
+
+	if sendServerHostname {
+		if host, err := os.Hostname(); err == nil {
+			doc.Hostname = host
+		}
+	}
+	return doc
+}
+
+// serveStructuredEcho writes the echo of req as a JSON or YAML document, or
+// as a Postman collection.
+func serveStructuredEcho(wr http.ResponseWriter, req *http.Request, code int, format, nonce string, sendServerHostname bool) {
+	doc := newEchoDocument(req, nonce, sendServerHostname)
+
+	var out []byte
+	var err error
+	switch format {
+	case "yaml":
+		out, err = yaml.Marshal(doc)
+	case "postman":
+		out, err = json.MarshalIndent(newPostmanCollection(req, doc), "", "  ")
+		out = append(out, '\n')
+	default:
+		out, err = json.MarshalIndent(doc, "", "  ")
+		out = append(out, '\n')
+	}
+	if err != nil {
+		writeError(wr, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	wr.Header().Set("Content-Type", echoFormatTypes[format])
+	wr.WriteHeader(code)
+	wr.Write(out) // nolint:errcheck
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestStructuredEcho verifies ?format= and Accept: application/yaml return the request as a JSON or YAML document
+func TestStructuredEcho(t *testing.T) {
+	do := func(t *testing.T, query, accept string) (*http.Response, []byte) {
+		t.Helper()
+
+		req, err := http.NewRequest("POST", httpBaseURL+"/structured"+query, strings.NewReader("hello"))
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("X-Custom", "value")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+		return resp, body
+	}
+
+	checkDocument := func(t *testing.T, doc map[string]interface{}) {
+		t.Helper()
+
+		for _, key := range []string{"method", "url", "proto", "host", "headers", "body"} {
+			if _, ok := doc[key]; !ok {
+				t.Errorf("expected key %q in %v", key, doc)
+			}
+		}
+		if doc["method"] != "POST" {
+			t.Errorf("expected method POST, got %v", doc["method"])
+		}
+		if doc["body"] != "hello" {
+			t.Errorf("expected body %q, got %v", "hello", doc["body"])
+		}
+		if headers, _ := doc["headers"].(map[string]interface{}); headers == nil || headers["X-Custom"] == nil {
+			t.Errorf("expected the X-Custom header, got %v", doc["headers"])
+		}
+	}
+
+	yamlTests := []struct {
+		name, query, accept string
+	}{
+		{"Query parameter", "?format=yaml", ""},
+		{"Accept header", "", "application/yaml"},
+	}
+
+	for _, tt := range yamlTests {
+		t.Run("YAML "+tt.name, func(t *testing.T) {
+			resp, body := do(t, tt.query, tt.accept)
+
+			if ct := resp.Header.Get("Content-Type"); ct != "application/yaml" {
+				t.Errorf("expected Content-Type application/yaml, got %q", ct)
+			}
+
+			var doc map[string]interface{}
+			if err := yaml.Unmarshal(body, &doc); err != nil {
+				t.Fatalf("failed to parse YAML: %v\n%s", err, body)
+			}
+			checkDocument(t, doc)
+		})
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		resp, body := do(t, "?format=json", "")
+
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected Content-Type application/json, got %q", ct)
+		}
+
+		var doc map[string]interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			t.Fatalf("failed to parse JSON: %v\n%s", err, body)
+		}
+		checkDocument(t, doc)
+	})
+
+	t.Run("Plain text by default", func(t *testing.T) {
+		resp, body := do(t, "", "")
+
+		if ct := resp.Header.Get("Content-Type"); ct != "text/plain" {
+			t.Errorf("expected Content-Type text/plain, got %q", ct)
+		}
+		if !strings.Contains(string(body), "POST /structured HTTP/1.1") {
+			t.Errorf("expected the plain-text echo, got %q", body)
+		}
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		resp, _ := do(t, "?format=xml", "")
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Log("TestStructuredEcho passed")
+}
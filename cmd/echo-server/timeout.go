@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// requestTimeoutStatus returns the status code of responses to requests that
+// exceed REQUEST_TIMEOUT, as configured by REQUEST_TIMEOUT_STATUS. It
+// defaults to 503.
+func requestTimeoutStatus() (int, error) {
+	v := os.Getenv("REQUEST_TIMEOUT_STATUS")
+	if v == "" {
+		return http.StatusServiceUnavailable, nil
+	}
+
+	code, err := strconv.Atoi(v)
+	if err != nil || code < 400 || code > 599 {
+		return 0, fmt.Errorf("REQUEST_TIMEOUT_STATUS: %q is not a valid error status code (400-599)", v)
+	}
+	return code, nil
+}
+
+// isLongLived reports whether req opens a stream that is long-lived by
+// design, a WebSocket or SSE stream, and so is exempt from REQUEST_TIMEOUT.
+func isLongLived(req *http.Request) bool {
+	return websocket.IsWebSocketUpgrade(req) || path.Base(req.URL.Path) == ".sse"
+}
+
+// timeoutMiddleware answers requests that take longer than REQUEST_TIMEOUT
+// with a JSON error in REQUEST_TIMEOUT_STATUS, unless the handler already
+// started its response. The handler's context is cancelled at the timeout so
+// it can stop, and anything it writes afterwards is discarded. Unlike
+// http.TimeoutHandler, responses are not buffered, so streamed responses
+// still reach the client as they are written.
+func timeoutMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout, _ := envDuration("REQUEST_TIMEOUT") // Validated at startup by validateConfig
+		if timeout <= 0 || isLongLived(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		tw := &timeoutWriter{w: w, header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+					return
+				}
+				close(done)
+			}()
+			next.ServeHTTP(tw, r.WithContext(ctx))
+		}()
+
+		// Panics are re-raised here, so recoverMiddleware still handles them
+		select {
+		case p := <-panicked:
+			panic(p)
+		case <-done:
+			tw.finish()
+			return
+		case <-ctx.Done():
+		}
+
+		tw.mu.Lock()
+		timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded) && !tw.wroteHeader
+		tw.timedOut = timedOut
+		tw.mu.Unlock()
+
+		if !timedOut {
+			// The client went away or the response already started, so let
+			// the handler wind down on its cancelled context
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.finish()
+			}
+			return
+		}
+
+		code, _ := requestTimeoutStatus() // Validated at startup by validateConfig
+		fmt.Printf("%s | timeout | %s %s exceeded %s\n", r.RemoteAddr, r.Method, r.URL, timeout)
+		writeError(w, code, fmt.Sprintf("Request exceeded the %s timeout", timeout))
+	})
+}
+
+// timeoutWriter is the response writer of a handler run by
+// timeoutMiddleware. The handler gets its own header map, copied to the
+// response when it starts writing, so that a timeout response is never mixed
+// with headers the handler set.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeaderLocked(code)
+}
+
+// writeHeaderLocked starts the response, unless it already started or the
+// request timed out. tw.mu must be held.
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+
+	dst := tw.w.Header()
+	for name, values := range tw.header {
+		dst[name] = values
+	}
+	tw.w.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.writeHeaderLocked(http.StatusOK)
+	return tw.w.Write(b)
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return
+	}
+	tw.writeHeaderLocked(http.StatusOK)
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish copies the handler's headers to the response when the handler
+// returned without writing anything, leaving the status to the server.
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	dst := tw.w.Header()
+	for name, values := range tw.header {
+		dst[name] = values
+	}
+}
+
+// Hijack takes over the connection for handlers that write the response
+// themselves, such as SLOW_HEADERS and ?reset=true. A hijacked response
+// counts as started, so the timeout no longer applies and timeoutMiddleware
+// waits for the handler instead of answering on the hijacked connection.
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	if tw.wroteHeader {
+		return nil, nil, errors.New("response already started, the connection cannot be hijacked")
+	}
+
+	conn, buf, err := http.NewResponseController(tw.w).Hijack()
+	if err == nil {
+		tw.wroteHeader = true
+	}
+	return conn, buf, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.w
+}
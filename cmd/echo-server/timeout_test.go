@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRequestTimeout verifies requests exceeding REQUEST_TIMEOUT get the configured status and a JSON body
+func TestRequestTimeout(t *testing.T) {
+	t.Setenv("REQUEST_TIMEOUT", "100ms")
+	t.Setenv("DELAY", "2s")
+
+	t.Run("Default status", func(t *testing.T) {
+		start := time.Now()
+		resp, err := http.Get(httpBaseURL + "/slow")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected the request to time out after 100ms, took %s", elapsed)
+		}
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected Content-Type application/json, got %q", ct)
+		}
+
+		var body map[string]string
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		if !strings.Contains(body["error"], "100ms") {
+			t.Errorf("expected the error to name the timeout, got %q", body["error"])
+		}
+	})
+
+	t.Run("Configured status", func(t *testing.T) {
+		t.Setenv("REQUEST_TIMEOUT_STATUS", "504")
+
+		resp, err := http.Get(httpBaseURL + "/slow")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusGatewayTimeout {
+			t.Errorf("expected status 504, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Within timeout", func(t *testing.T) {
+		t.Setenv("DELAY", "")
+
+		resp, err := http.Get(httpBaseURL + "/fast")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "GET /fast") {
+			t.Errorf("expected the echo, got status %d: %s", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("SSE exempt", func(t *testing.T) {
+		t.Setenv("DELAY", "")
+
+		resp, err := http.Get(httpBaseURL + "/.sse")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		// The stream sends an event every second, well past the timeout
+		reader := bufio.NewReader(resp.Body)
+		deadline := time.Now().Add(1500 * time.Millisecond)
+		for time.Now().Before(deadline) {
+			if _, err := reader.ReadString('\n'); err != nil {
+				t.Fatalf("expected the stream to outlive the timeout: %v", err)
+			}
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Hijacked connection", func(t *testing.T) {
+		t.Setenv("DELAY", "")
+		t.Setenv("SLOW_HEADERS", "50ms")
+
+		var resp *http.Response
+		out := captureStdout(t, func() {
+			var err error
+			resp, err = http.Get(httpBaseURL + "/slow-hijacked")
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			io.Copy(io.Discard, resp.Body) // nolint:errcheck
+			resp.Body.Close()
+		})
+
+		// The slow headers outlast the timeout, but the handler took over the
+		// connection before it fired
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status 200, got %d", resp.StatusCode)
+		}
+		if strings.Contains(out, "| timeout |") {
+			t.Errorf("expected no timeout response on the hijacked connection, got: %s", out)
+		}
+	})
+
+	t.Log("TestRequestTimeout passed")
+}
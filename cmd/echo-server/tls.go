@@ -0,0 +1,202 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// newTLSConfig returns the TLS configuration for the HTTPS listener, built
+// from the certificate and key in TLS_CERT_FILE and TLS_KEY_FILE. It returns
+// nil when TLS is not configured.
+func newTLSConfig() (*tls.Config, error) {
+	certFile := os.Getenv("TLS_CERT_FILE")
+	keyFile := os.Getenv("TLS_KEY_FILE")
+
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %v", err)
+	}
+
+	minVersion, err := tlsMinVersion()
+	if err != nil {
+		return nil, err
+	}
+	cipherSuites, err := tlsCipherSuites()
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates:       []tls.Certificate{cert},
+		GetConfigForClient: recordClientHello,
+		MinVersion:         minVersion,
+		CipherSuites:       cipherSuites,
+	}, nil
+}
+
+// tlsVersions maps the TLS_MIN_VERSION values to TLS versions.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsMinVersion returns the minimum TLS version configured by
+// TLS_MIN_VERSION, e.g. "1.3". It returns zero, Go's default, when unset.
+func tlsMinVersion() (uint16, error) {
+	v := os.Getenv("TLS_MIN_VERSION")
+	if v == "" {
+		return 0, nil
+	}
+
+	version, ok := tlsVersions[strings.TrimPrefix(strings.ToUpper(v), "TLS")]
+	if !ok {
+		return 0, fmt.Errorf("TLS_MIN_VERSION: %q must be one of 1.0, 1.1, 1.2 or 1.3", v)
+	}
+	return version, nil
+}
+
+// tlsCipherSuites returns the TLS 1.0-1.2 cipher suites allowed by
+// TLS_CIPHER_SUITES, a comma-separated list of names such as
+// "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". It returns nil, Go's default
+// list, when unset. TLS 1.3 suites cannot be restricted in Go, so naming one
+// is an error.
+func tlsCipherSuites() ([]uint16, error) {
+	v := os.Getenv("TLS_CIPHER_SUITES")
+	if v == "" {
+		return nil, nil
+	}
+
+	known := map[string]*tls.CipherSuite{}
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		known[suite.Name] = suite
+	}
+
+	var ids []uint16
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+
+		suite, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("TLS_CIPHER_SUITES: unknown cipher suite %q", name)
+		}
+		if len(suite.SupportedVersions) == 1 && suite.SupportedVersions[0] == tls.VersionTLS13 {
+			return nil, fmt.Errorf("TLS_CIPHER_SUITES: %q is a TLS 1.3 suite, which cannot be configured", name)
+		}
+		ids = append(ids, suite.ID)
+	}
+	return ids, nil
+}
+
+// newTLSServer returns an HTTPS server serving handler with the given TLS
+// configuration.
+func newTLSServer(handler http.Handler, cfg *tls.Config) *http.Server {
+	return &http.Server{
+		Handler:     handler,
+		TLSConfig:   cfg,
+		ConnState:   forgetClientHello,
+		ConnContext: connContext,
+	}
+}
+
+// clientHellos holds a summary of the TLS ClientHello of every open TLS
+// connection.
+//
+// The ClientHello is only visible during the handshake, before any request is
+// read, so it is recorded by the GetConfigForClient callback and keyed by the
+// connection's remote address. Requests are matched to it through
+// req.RemoteAddr, which is the same address, and entries are removed by the
+// server's ConnState hook when the connection closes.
+var clientHellos sync.Map // map[string]*clientHelloSummary
+
+// clientHelloSummary is the subset of a TLS ClientHello that is echoed back.
+type clientHelloSummary struct {
+	ServerName        string
+	SupportedVersions []string
+	CipherSuites      []string
+	Extensions        []uint16
+	SupportedCurves   []string
+	SupportedPoints   []uint8
+	SignatureSchemes  []string
+	ALPNProtocols     []string
+}
+
+// recordClientHello is a tls.Config.GetConfigForClient callback that records
+// the ClientHello for the connection. It never alters the configuration.
+func recordClientHello(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+	summary := &clientHelloSummary{
+		ServerName:      hello.ServerName,
+		Extensions:      hello.Extensions,
+		SupportedPoints: hello.SupportedPoints,
+		ALPNProtocols:   hello.SupportedProtos,
+	}
+
+	for _, v := range hello.SupportedVersions {
+		summary.SupportedVersions = append(summary.SupportedVersions, tls.VersionName(v))
+	}
+	for _, c := range hello.CipherSuites {
+		summary.CipherSuites = append(summary.CipherSuites, tls.CipherSuiteName(c))
+	}
+	for _, c := range hello.SupportedCurves {
+		summary.SupportedCurves = append(summary.SupportedCurves, c.String())
+	}
+	for _, s := range hello.SignatureSchemes {
+		summary.SignatureSchemes = append(summary.SignatureSchemes, s.String())
+	}
+
+	clientHellos.Store(hello.Conn.RemoteAddr().String(), summary)
+	return nil, nil
+}
+
+// forgetClientHello is an http.Server.ConnState hook that discards the
+// ClientHello of closed connections.
+func forgetClientHello(conn net.Conn, state http.ConnState) {
+	if state == http.StateClosed || state == http.StateHijacked {
+		clientHellos.Delete(conn.RemoteAddr().String())
+	}
+}
+
+// writeClientHello writes the ClientHello summary of the request's connection
+// to w, if one was recorded.
+func writeClientHello(w io.Writer, req *http.Request) {
+	v, ok := clientHellos.Load(req.RemoteAddr)
+	if !ok {
+		return
+	}
+	hello := v.(*clientHelloSummary)
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "TLS ClientHello")
+	fmt.Fprintf(w, "  Server name: %s\n", hello.ServerName)
+	fmt.Fprintf(w, "  Supported versions: %s\n", strings.Join(hello.SupportedVersions, ", "))
+	fmt.Fprintf(w, "  Cipher suites: %s\n", strings.Join(hello.CipherSuites, ", "))
+	fmt.Fprintf(w, "  Extensions: %s\n", joinNumbers(hello.Extensions))
+	fmt.Fprintf(w, "  Supported curves: %s\n", strings.Join(hello.SupportedCurves, ", "))
+	fmt.Fprintf(w, "  Point formats: %s\n", joinNumbers(hello.SupportedPoints))
+	fmt.Fprintf(w, "  Signature schemes: %s\n", strings.Join(hello.SignatureSchemes, ", "))
+	fmt.Fprintf(w, "  ALPN protocols: %s\n", strings.Join(hello.ALPNProtocols, ", "))
+}
+
+// joinNumbers formats a list of numbers as a comma-separated string.
+func joinNumbers[T uint8 | uint16](values []T) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ", ")
+}
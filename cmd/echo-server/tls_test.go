@@ -0,0 +1,206 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes a self-signed certificate for localhost to a
+// temporary directory and returns the certificate and key file paths.
+func writeTestCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	return certFile, keyFile
+}
+
+// startTestTLSServer starts an HTTPS echo server configured from the
+// environment and returns its base URL.
+func startTestTLSServer(t *testing.T) string {
+	t.Helper()
+
+	certFile, keyFile := writeTestCertificate(t)
+	t.Setenv("TLS_CERT_FILE", certFile)
+	t.Setenv("TLS_KEY_FILE", keyFile)
+
+	cfg, err := newTLSConfig()
+	if err != nil {
+		t.Fatalf("failed to create TLS config: %v", err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	server := newTLSServer(createRouter(), cfg)
+	go server.ServeTLS(lis, "", "") // nolint:errcheck
+	t.Cleanup(func() { server.Close() })
+
+	return "https://" + lis.Addr().String()
+}
+
+// insecureTLSClient returns an HTTP client that accepts the self-signed test certificate.
+func insecureTLSClient(cfg *tls.Config) *http.Client {
+	if cfg == nil {
+		cfg = &tls.Config{}
+	}
+	cfg.InsecureSkipVerify = true
+
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: cfg},
+		Timeout:   5 * time.Second,
+	}
+}
+
+// TestTLSClientHelloEcho verifies the ClientHello summary is echoed over TLS
+func TestTLSClientHelloEcho(t *testing.T) {
+	t.Setenv("SEND_TLS_CLIENT_HELLO", "true")
+	baseURL := startTestTLSServer(t)
+
+	client := insecureTLSClient(&tls.Config{ServerName: "echo.test"})
+
+	resp, err := client.Get(baseURL + "/hello")
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read response body: %v", err)
+	}
+
+	for _, want := range []string{
+		"TLS ClientHello",
+		"Server name: echo.test",
+		"Cipher suites: ",
+		"Extensions: ",
+		"TLS 1.3",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected response to contain %q, got: %s", want, body)
+		}
+	}
+
+	t.Log("TestTLSClientHelloEcho passed")
+}
+
+// TestTLSConfigValidation verifies that a certificate without a key is rejected
+func TestTLSConfigValidation(t *testing.T) {
+	t.Setenv("TLS_CERT_FILE", "cert.pem")
+	t.Setenv("TLS_KEY_FILE", "")
+
+	if _, err := newTLSConfig(); err == nil {
+		t.Error("expected error when only TLS_CERT_FILE is set")
+	}
+
+	t.Log("TestTLSConfigValidation passed")
+}
+
+// TestTLSMinVersion verifies clients below TLS_MIN_VERSION fail the handshake
+func TestTLSMinVersion(t *testing.T) {
+	t.Setenv("TLS_MIN_VERSION", "1.3")
+	baseURL := startTestTLSServer(t)
+
+	t.Run("Disallowed version", func(t *testing.T) {
+		client := insecureTLSClient(&tls.Config{MaxVersion: tls.VersionTLS12})
+
+		resp, err := client.Get(baseURL + "/")
+		if err == nil {
+			resp.Body.Close()
+			t.Fatal("expected the TLS 1.2 handshake to fail")
+		}
+	})
+
+	t.Run("Allowed version", func(t *testing.T) {
+		client := insecureTLSClient(nil)
+
+		resp, err := client.Get(baseURL + "/")
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.TLS == nil || resp.TLS.Version != tls.VersionTLS13 {
+			t.Errorf("expected TLS 1.3, got %v", resp.TLS)
+		}
+	})
+
+	t.Log("TestTLSMinVersion passed")
+}
+
+// TestTLSPolicyValidation verifies unknown TLS versions and cipher suites are rejected
+func TestTLSPolicyValidation(t *testing.T) {
+	t.Setenv("TLS_MIN_VERSION", "1.4")
+	if _, err := tlsMinVersion(); err == nil {
+		t.Error("expected error for TLS_MIN_VERSION=1.4")
+	}
+
+	for _, v := range []string{"TLS_NOT_A_SUITE", "TLS_AES_128_GCM_SHA256"} {
+		t.Setenv("TLS_CIPHER_SUITES", v)
+		if _, err := tlsCipherSuites(); err == nil {
+			t.Errorf("expected error for TLS_CIPHER_SUITES=%q", v)
+		}
+	}
+
+	t.Setenv("TLS_CIPHER_SUITES", "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256")
+	if suites, err := tlsCipherSuites(); err != nil || len(suites) != 2 {
+		t.Errorf("expected 2 cipher suites, got %v, %v", suites, err)
+	}
+
+	t.Log("TestTLSPolicyValidation passed")
+}
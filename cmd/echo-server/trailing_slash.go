@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Trailing-slash handling modes, as set by TRAILING_SLASH.
+const (
+	// trailingSlashStrict treats "/a/" and "/a" as distinct paths.
+	trailingSlashStrict = "strict"
+
+	// trailingSlashRedirect redirects "/a/" to "/a".
+	trailingSlashRedirect = "redirect"
+
+	// trailingSlashStrip serves "/a/" as if "/a" had been requested.
+	trailingSlashStrip = "strip"
+)
+
+// trailingSlashMode returns the trailing-slash handling mode configured by
+// TRAILING_SLASH. It defaults to strict.
+func trailingSlashMode() (string, error) {
+	mode := strings.ToLower(os.Getenv("TRAILING_SLASH"))
+	switch mode {
+	case "":
+		return trailingSlashStrict, nil
+	case trailingSlashStrict, trailingSlashRedirect, trailingSlashStrip:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("TRAILING_SLASH: %q must be strict, redirect or strip", mode)
+	}
+}
+
+// trailingSlashHandler applies the trailing-slash mode to requests before
+// they are routed. The root path "/" is never changed.
+//
+// Redirects use 301 for GET and HEAD and 308 otherwise, so clients repeat
+// other methods with their body.
+func trailingSlashHandler(mode string, next http.Handler) http.Handler {
+	if mode == trailingSlashStrict {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" || !strings.HasSuffix(r.URL.Path, "/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// This runs before mux cleans the path, so leading slashes are also
+		// collapsed: "//host/" must not redirect to the network-path
+		// reference "//host".
+		u := *r.URL
+		u.Path = "/" + strings.Trim(u.Path, "/")
+		u.RawPath = ""
+
+		if mode == trailingSlashRedirect {
+			code := http.StatusPermanentRedirect
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				code = http.StatusMovedPermanently
+			}
+			http.Redirect(w, r, u.RequestURI(), code)
+			return
+		}
+
+		r2 := r.Clone(r.Context())
+		r2.URL = &u
+		r2.RequestURI = u.RequestURI()
+		next.ServeHTTP(w, r2)
+	})
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestTrailingSlash verifies each trailing-slash mode with and without a trailing slash
+func TestTrailingSlash(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		method       string
+		path         string
+		wantStatus   int
+		wantLocation string
+		wantPetStore bool
+	}{
+		// In strict mode the slashed path falls through to the echo handler
+		{"Strict without slash", "", "GET", "/v1/pets/1", http.StatusOK, "", true},
+		{"Strict with slash", "", "GET", "/v1/pets/1/", http.StatusOK, "", false},
+		{"Redirect without slash", "redirect", "GET", "/v1/pets/1", http.StatusOK, "", true},
+		{"Redirect with slash", "redirect", "GET", "/v1/pets/1/?x=1", http.StatusMovedPermanently, "/v1/pets/1?x=1", false},
+		{"Redirect POST with slash", "redirect", "POST", "/v1/pets/", http.StatusPermanentRedirect, "/v1/pets", false},
+		{"Redirect root", "redirect", "GET", "/", http.StatusOK, "", false},
+		{"Redirect leading slashes", "redirect", "GET", "//evil.com/", http.StatusMovedPermanently, "/evil.com", false},
+		{"Strip without slash", "strip", "GET", "/v1/pets/1", http.StatusOK, "", true},
+		{"Strip with slash", "strip", "GET", "/v1/pets/1/", http.StatusOK, "", true},
+	}
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRAILING_SLASH", tt.mode)
+
+			server := httptest.NewServer(createRouter())
+			defer server.Close()
+
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			if location := resp.Header.Get("Location"); location != tt.wantLocation {
+				t.Errorf("expected Location %q, got %q", tt.wantLocation, location)
+			}
+
+			// Only the PetStore route answers with JSON
+			if isPetStore := resp.Header.Get("Content-Type") == "application/json"; isPetStore != tt.wantPetStore {
+				t.Errorf("expected PetStore response %v, got Content-Type %q", tt.wantPetStore, resp.Header.Get("Content-Type"))
+			}
+		})
+	}
+
+	t.Run("Invalid mode", func(t *testing.T) {
+		t.Setenv("TRAILING_SLASH", "sometimes")
+
+		if _, err := trailingSlashMode(); err == nil {
+			t.Error("expected error for an invalid mode")
+		}
+	})
+
+	t.Log("TestTrailingSlash passed")
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// headerTokens returns the comma-separated tokens of every value of the
+// header name, trimmed and with empty tokens dropped.
+func headerTokens(h http.Header, name string) []string {
+	var tokens []string
+	for _, v := range h.Values(name) {
+		for _, token := range strings.Split(v, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+// containsToken reports whether tokens contains token, ignoring case.
+func containsToken(tokens []string, token string) bool {
+	for _, t := range tokens {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// upgradeDecision explains whether and why the request was or was not
+// upgraded to another protocol. WebSocket upgrades are handled by the echo
+// handler and h2c upgrades by the h2c wrapper around the router, which only
+// passes on requests it did not upgrade.
+func upgradeDecision(req *http.Request) string {
+	offers := headerTokens(req.Header, "Upgrade")
+	connection := headerTokens(req.Header, "Connection")
+
+	switch {
+	case len(offers) == 0 && containsToken(connection, "upgrade"):
+		return "not upgraded: Connection lists upgrade but there is no Upgrade header"
+	case len(offers) == 0:
+		return "no upgrade requested"
+	case req.ProtoMajor >= 2:
+		return fmt.Sprintf("not upgraded: Upgrade is not allowed in %s", req.Proto)
+	case !containsToken(connection, "upgrade"):
+		return "not upgraded: Connection does not list the upgrade token, so the Upgrade header is ignored"
+	case websocket.IsWebSocketUpgrade(req):
+		return "upgrading to websocket"
+	case containsToken(offers, "h2c") && (!containsToken(connection, "HTTP2-Settings") || req.Header.Get("HTTP2-Settings") == ""):
+		return "not upgraded to h2c: requires an HTTP2-Settings header also listed in Connection"
+	case containsToken(offers, "h2c"):
+		return "not upgraded to h2c: the h2c handshake was rejected"
+	default:
+		return "not upgraded: no supported protocol offered (supported: websocket, h2c)"
+	}
+}
+
+// writeUpgradeInfo writes the protocol upgrade offers of the request, the
+// Connection tokens and the upgrade decision to w, if the request offers or
+// mentions an upgrade.
+func writeUpgradeInfo(w io.Writer, req *http.Request) {
+	offers := headerTokens(req.Header, "Upgrade")
+	connection := headerTokens(req.Header, "Connection")
+	if len(offers) == 0 && !containsToken(connection, "upgrade") {
+		return
+	}
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Upgrade")
+	fmt.Fprintf(w, "  Offers: %s\n", strings.Join(offers, ", "))
+	fmt.Fprintf(w, "  Connection tokens: %s\n", strings.Join(connection, ", "))
+	fmt.Fprintf(w, "  Decision: %s\n", upgradeDecision(req))
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestUpgradeInfo verifies upgrade offers and the upgrade decision are echoed
+func TestUpgradeInfo(t *testing.T) {
+	t.Setenv("SEND_UPGRADE_INFO", "true")
+
+	tests := []struct {
+		name       string
+		upgrade    string
+		connection string
+		want       []string
+	}{
+		{
+			"Misspelled protocol",
+			"websockets",
+			"Upgrade",
+			[]string{"Offers: websockets", "Connection tokens: Upgrade", "Decision: not upgraded: no supported protocol offered"},
+		},
+		{
+			"Missing upgrade token",
+			"websocket",
+			"keep-alive",
+			[]string{"Offers: websocket", "Decision: not upgraded: Connection does not list the upgrade token"},
+		},
+		{
+			"Incomplete h2c offer",
+			"h2c",
+			"Upgrade",
+			[]string{"Offers: h2c", "Decision: not upgraded to h2c: requires an HTTP2-Settings header"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", httpBaseURL+"/", nil)
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			req.Header.Set("Upgrade", tt.upgrade)
+			req.Header.Set("Connection", tt.connection)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("expected response to contain %q, got: %s", want, body)
+				}
+			}
+		})
+	}
+
+	t.Log("TestUpgradeInfo passed")
+}
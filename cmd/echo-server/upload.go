@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// uploadHandler handles POST /upload. It reads and discards the request body
+// and reports the number of bytes received and the read throughput, for
+// benchmarking client upload performance. The body is limited to
+// MAX_BODY_BYTES when set.
+func uploadHandler(w http.ResponseWriter, r *http.Request) {
+	body := io.Reader(r.Body)
+	if maxBytes, _ := envInt("MAX_BODY_BYTES"); maxBytes > 0 {
+		body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
+	}
+
+	counter := &countingReader{r: body}
+
+	start := time.Now()
+	_, err := io.Copy(io.Discard, counter)
+	elapsed := time.Since(start)
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds the maximum of %d bytes", maxBytesErr.Limit))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	var throughput float64
+	if elapsed > 0 {
+		throughput = float64(counter.n) / elapsed.Seconds()
+	}
+
+	fmt.Printf("%s | upload | %d byte(s) in %s\n", r.RemoteAddr, counter.n, elapsed)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"bytes":            counter.n,
+		"duration_ms":      float64(elapsed.Microseconds()) / 1000,
+		"bytes_per_second": throughput,
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// TestUploadEndpoint verifies the upload endpoint reports the received byte count
+func TestUploadEndpoint(t *testing.T) {
+	const size = 256 * 1024
+
+	resp, err := http.Post(httpBaseURL+"/upload", "application/octet-stream", bytes.NewReader(make([]byte, size)))
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Bytes          int64   `json:"bytes"`
+		BytesPerSecond float64 `json:"bytes_per_second"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if result.Bytes != size {
+		t.Errorf("expected %d bytes, got %d", size, result.Bytes)
+	}
+
+	if result.BytesPerSecond <= 0 {
+		t.Errorf("expected positive throughput, got %f", result.BytesPerSecond)
+	}
+
+	t.Run("Body too large", func(t *testing.T) {
+		t.Setenv("MAX_BODY_BYTES", "1024")
+
+		resp, err := http.Post(httpBaseURL+"/upload", "application/octet-stream", bytes.NewReader(make([]byte, 2048)))
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("expected status 413, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Log("TestUploadEndpoint passed")
+}
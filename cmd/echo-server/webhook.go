@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// webhookQueueSize is the number of events that may wait for delivery to the
+// webhook. Events beyond it are dropped rather than slowing down echoes.
+const webhookQueueSize = 256
+
+// webhookTimeout bounds the delivery of a single event to the webhook.
+const webhookTimeout = 5 * time.Second
+
+// maxWebhookBody caps the request body included in webhook events. Longer
+// bodies are truncated.
+const maxWebhookBody = 64 * 1024
+
+// webhookEvent is a received request as POSTed to WEBHOOK_URL.
+type webhookEvent struct {
+	Time          time.Time           `json:"time"`
+	RemoteAddr    string              `json:"remoteAddr"`
+	Method        string              `json:"method"`
+	URL           string              `json:"url"`
+	Proto         string              `json:"proto"`
+	Host          string              `json:"host"`
+	Headers       map[string][]string `json:"headers"`
+	Body          string              `json:"body"`
+	BodyTruncated bool                `json:"bodyTruncated,omitempty"`
+}
+
+// webhookDelivery is a queued event together with the webhook it goes to.
+type webhookDelivery struct {
+	target string
+	event  webhookEvent
+}
+
+// webhookSender delivers events to webhooks from a single background
+// goroutine, started on first use, so that echoes never wait on the webhook.
+type webhookSender struct {
+	once    sync.Once
+	queue   chan webhookDelivery
+	client  *http.Client
+	dropped atomic.Int64
+}
+
+// webhooks is the sender used for WEBHOOK_URL.
+var webhooks = &webhookSender{
+	queue:  make(chan webhookDelivery, webhookQueueSize),
+	client: &http.Client{Timeout: webhookTimeout},
+}
+
+// webhookURL returns the absolute http(s) URL configured by WEBHOOK_URL, or ""
+// when unset.
+func webhookURL() (string, error) {
+	v := os.Getenv("WEBHOOK_URL")
+	if v == "" {
+		return "", nil
+	}
+
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("WEBHOOK_URL: %q is not an absolute http or https URL", v)
+	}
+	return v, nil
+}
+
+// enqueue queues event for delivery to target, starting the delivery
+// goroutine if needed. It drops the event and reports false when the queue is
+// full.
+func (s *webhookSender) enqueue(target string, event webhookEvent) bool {
+	s.once.Do(func() { go s.run() })
+
+	select {
+	case s.queue <- webhookDelivery{target: target, event: event}:
+		return true
+	default:
+		s.dropped.Add(1)
+		return false
+	}
+}
+
+// run delivers queued events one at a time, logging failed deliveries.
+func (s *webhookSender) run() {
+	for d := range s.queue {
+		if err := s.deliver(d); err != nil {
+			fmt.Printf("webhook | failed to deliver event for %s %s: %v\n", d.event.Method, d.event.URL, err)
+		}
+	}
+}
+
+// deliver POSTs the event of d as JSON to its webhook, treating any non-2xx
+// response as a failure.
+func (s *webhookSender) deliver(d webhookDelivery) error {
+	payload, err := json.Marshal(d.event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(d.target, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) // nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// sendWebhookEvent queues req as an event for WEBHOOK_URL, when set. The body
+// is buffered, up to maxWebhookBody bytes, and restored so it can still be
+// echoed. Events that do not fit in the queue are dropped and counted.
+func sendWebhookEvent(req *http.Request) {
+	target, _ := webhookURL() // Validated at startup by validateConfig
+	if target == "" {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxWebhookBody+1))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+	if err != nil {
+		body = nil
+	}
+
+	event := webhookEvent{
+		Time:       time.Now().UTC(),
+		RemoteAddr: req.RemoteAddr,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Proto:      req.Proto,
+		Host:       req.Host,
+		Headers:    req.Header.Clone(),
+	}
+	if len(body) > maxWebhookBody {
+		body, event.BodyTruncated = body[:maxWebhookBody], true
+	}
+	event.Body = string(body)
+
+	if !webhooks.enqueue(target, event) {
+		fmt.Printf("%s | webhook | queue full, dropped event (%d dropped so far)\n", req.RemoteAddr, webhooks.dropped.Load())
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWebhook verifies echoed requests are POSTed as JSON events to WEBHOOK_URL
+func TestWebhook(t *testing.T) {
+	events := make(chan webhookEvent, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected Content-Type application/json, got %q", ct)
+		}
+
+		var event webhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("failed to decode event: %v", err)
+		}
+		if event.URL == "/webhooked?x=1" {
+			events <- event
+		}
+	}))
+	defer receiver.Close()
+
+	t.Setenv("WEBHOOK_URL", receiver.URL)
+
+	resp, err := http.Post(httpBaseURL+"/webhooked?x=1", "text/plain", strings.NewReader("webhook body"))
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if !strings.Contains(string(body), "webhook body") {
+		t.Errorf("expected the body to still be echoed, got: %s", body)
+	}
+
+	select {
+	case event := <-events:
+		if event.Method != "POST" || event.Body != "webhook body" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+		if got := event.Headers["Content-Type"]; len(got) != 1 || got[0] != "text/plain" {
+			t.Errorf("expected Content-Type header in event, got %v", got)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("webhook did not receive the event")
+	}
+
+	t.Run("Invalid URL", func(t *testing.T) {
+		t.Setenv("WEBHOOK_URL", "not a url")
+		if _, err := webhookURL(); err == nil {
+			t.Error("expected an error for a relative URL")
+		}
+	})
+
+	t.Run("Queue full", func(t *testing.T) {
+		// A sender whose queue is full drops events instead of blocking
+		sender := &webhookSender{queue: make(chan webhookDelivery)}
+		sender.once.Do(func() {})
+
+		if sender.enqueue(receiver.URL, webhookEvent{}) {
+			t.Fatal("expected the event to be dropped")
+		}
+		if n := sender.dropped.Load(); n != 1 {
+			t.Errorf("expected 1 dropped event, got %d", n)
+		}
+	})
+
+	t.Log("TestWebhook passed")
+}
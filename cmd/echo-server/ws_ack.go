@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket acknowledgment modes selected by the "ack" query parameter.
+const (
+	// wsAckOff echoes messages without acknowledging them.
+	wsAckOff = ""
+
+	// wsAckAlso acknowledges every message before echoing it.
+	wsAckAlso = "true"
+
+	// wsAckOnly acknowledges every message instead of echoing it.
+	wsAckOnly = "only"
+)
+
+// wsAck acknowledges the receipt of a WebSocket message.
+type wsAck struct {
+	// Seq counts the messages received on the connection, from 1.
+	Seq int `json:"seq"`
+	// Bytes is the size of the message payload.
+	Bytes    int  `json:"bytes"`
+	Received bool `json:"received"`
+}
+
+// wsAckMode returns the acknowledgment mode requested by the "ack" query
+// parameter: "true" to acknowledge each message in addition to echoing it,
+// or "only" to acknowledge it instead.
+func wsAckMode(req *http.Request) (string, error) {
+	switch v := req.URL.Query().Get("ack"); v {
+	case wsAckOff, wsAckAlso, wsAckOnly:
+		return v, nil
+	default:
+		return "", fmt.Errorf("Invalid ack %q, must be true or only", v)
+	}
+}
+
+// writeWSAck sends the acknowledgment of the seq-th message received on the
+// connection, whose payload was size bytes, as a JSON text message.
+func writeWSAck(writer *wsWriter, seq, size int) error {
+	ack, err := json.Marshal(wsAck{Seq: seq, Bytes: size, Received: true})
+	if err != nil {
+		return err
+	}
+	return writer.WriteMessage(websocket.TextMessage, ack)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestWebSocketAck verifies the ack query parameter acknowledges every message with its sequence number and size
+func TestWebSocketAck(t *testing.T) {
+	// dial connects with the given ack mode and skips the greeting
+	dial := func(t *testing.T, mode string) *websocket.Conn {
+		t.Helper()
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws://localhost:"+testHTTPPort+"/ws?ack="+mode, nil)
+		if err != nil {
+			t.Fatalf("failed to connect to WebSocket: %v", err)
+		}
+		conn.SetReadDeadline(time.Now().Add(3 * time.Second)) // nolint:errcheck
+
+		if _, _, err := conn.ReadMessage(); err != nil {
+			t.Fatalf("failed to read greeting: %v", err)
+		}
+		return conn
+	}
+
+	// readAck reads the next message, which must be an acknowledgment
+	readAck := func(t *testing.T, conn *websocket.Conn) wsAck {
+		t.Helper()
+
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("failed to read ack: %v", err)
+		}
+		if messageType != websocket.TextMessage {
+			t.Fatalf("expected the ack in a text message, got type %d", messageType)
+		}
+
+		var ack wsAck
+		if err := json.Unmarshal(message, &ack); err != nil {
+			t.Fatalf("failed to parse ack %q: %v", message, err)
+		}
+		return ack
+	}
+
+	t.Run("Ack only", func(t *testing.T) {
+		conn := dial(t, "only")
+		defer conn.Close()
+
+		messages := []struct {
+			messageType int
+			data        []byte
+		}{
+			{websocket.TextMessage, []byte("hello")},
+			{websocket.BinaryMessage, []byte{0x00, 0x01, 0x02}},
+			{websocket.TextMessage, []byte("hello again")},
+		}
+
+		for i, m := range messages {
+			if err := conn.WriteMessage(m.messageType, m.data); err != nil {
+				t.Fatalf("failed to write message %d: %v", i+1, err)
+			}
+
+			want := wsAck{Seq: i + 1, Bytes: len(m.data), Received: true}
+			if ack := readAck(t, conn); ack != want {
+				t.Errorf("expected ack %+v, got %+v", want, ack)
+			}
+		}
+	})
+
+	t.Run("Ack and echo", func(t *testing.T) {
+		conn := dial(t, "true")
+		defer conn.Close()
+
+		if err := conn.WriteMessage(websocket.TextMessage, []byte("echo me")); err != nil {
+			t.Fatalf("failed to write message: %v", err)
+		}
+
+		want := wsAck{Seq: 1, Bytes: len("echo me"), Received: true}
+		if ack := readAck(t, conn); ack != want {
+			t.Errorf("expected ack %+v, got %+v", want, ack)
+		}
+		if _, message, err := conn.ReadMessage(); err != nil || string(message) != "echo me" {
+			t.Errorf("expected the echo after the ack, got %q (%v)", message, err)
+		}
+	})
+
+	t.Run("Invalid mode", func(t *testing.T) {
+		_, resp, err := websocket.DefaultDialer.Dial("ws://localhost:"+testHTTPPort+"/ws?ack=yes", nil)
+		if err == nil {
+			t.Fatal("expected the handshake to fail")
+		}
+		if resp == nil || resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %v", resp)
+		}
+	})
+
+	t.Log("TestWebSocketAck passed")
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+)
+
+// wsFilterCache holds the compiled WS_ECHO_FILTER, so the expression is
+// compiled once rather than for every connection.
+var wsFilterCache struct {
+	mu     sync.Mutex
+	source string
+	re     *regexp.Regexp
+}
+
+// wsEchoFilter returns the regular expression WebSocket messages must match
+// to be echoed, as configured by WS_ECHO_FILTER. It returns nil, echoing
+// every message, when unset.
+func wsEchoFilter() (*regexp.Regexp, error) {
+	v := os.Getenv("WS_ECHO_FILTER")
+	if v == "" {
+		return nil, nil
+	}
+
+	wsFilterCache.mu.Lock()
+	defer wsFilterCache.mu.Unlock()
+
+	if wsFilterCache.re != nil && wsFilterCache.source == v {
+		return wsFilterCache.re, nil
+	}
+
+	re, err := regexp.Compile(v)
+	if err != nil {
+		return nil, fmt.Errorf("WS_ECHO_FILTER: %v", err)
+	}
+	wsFilterCache.source, wsFilterCache.re = v, re
+	return re, nil
+}
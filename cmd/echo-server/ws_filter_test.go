@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestWebSocketEchoFilter verifies only messages matching WS_ECHO_FILTER are echoed
+func TestWebSocketEchoFilter(t *testing.T) {
+	t.Setenv("WS_ECHO_FILTER", "^ping")
+
+	tests := []struct {
+		name  string
+		reply string
+		want  []string
+	}{
+		{"Dropped silently", "", []string{"ping 1", "ping 2"}},
+		{"Dropped with reply", "true", []string{"ping 1", "Message dropped: it does not match WS_ECHO_FILTER", "ping 2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WS_ECHO_FILTER_REPLY", tt.reply)
+
+			conn, _, err := websocket.DefaultDialer.Dial("ws://localhost:"+testHTTPPort+"/ws", nil)
+			if err != nil {
+				t.Fatalf("failed to connect to WebSocket: %v", err)
+			}
+			defer conn.Close()
+
+			// Skip the greeting
+			if _, _, err := conn.ReadMessage(); err != nil {
+				t.Fatalf("failed to read greeting: %v", err)
+			}
+
+			for _, message := range []string{"ping 1", "hello", "ping 2"} {
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+					t.Fatalf("failed to write message: %v", err)
+				}
+			}
+
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second)) // nolint:errcheck
+			for _, want := range tt.want {
+				_, message, err := conn.ReadMessage()
+				if err != nil {
+					t.Fatalf("failed to read message: %v", err)
+				}
+				if string(message) != want {
+					t.Errorf("expected %q, got %q", want, message)
+				}
+			}
+
+			// Nothing else arrives
+			conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond)) // nolint:errcheck
+			if _, message, err := conn.ReadMessage(); err == nil {
+				t.Errorf("expected no further messages, got %q", message)
+			}
+		})
+	}
+
+	t.Log("TestWebSocketEchoFilter passed")
+}
+
+// TestWebSocketEchoFilterValidation verifies that invalid filters are rejected
+func TestWebSocketEchoFilterValidation(t *testing.T) {
+	t.Setenv("WS_ECHO_FILTER", "(unclosed")
+	if _, err := wsEchoFilter(); err == nil {
+		t.Error("expected error for an invalid WS_ECHO_FILTER")
+	}
+
+	t.Log("TestWebSocketEchoFilterValidation passed")
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"text/template"
+)
+
+// wsGreetingData is the data available to the WS_GREETING template.
+type wsGreetingData struct {
+	Hostname string
+}
+
+// wsGreetingCache holds the parsed WS_GREETING, so the template is parsed
+// once rather than for every connection.
+var wsGreetingCache struct {
+	mu     sync.Mutex
+	source string
+	tmpl   *template.Template
+}
+
+// wsGreetingTemplate parses the WebSocket greeting template configured by
+// WS_GREETING. It returns nil when the variable is unset, in which case the
+// default greeting is used.
+func wsGreetingTemplate() (*template.Template, error) {
+	v, ok := os.LookupEnv("WS_GREETING")
+	if !ok {
+		return nil, nil
+	}
+
+	wsGreetingCache.mu.Lock()
+	defer wsGreetingCache.mu.Unlock()
+
+	if wsGreetingCache.tmpl != nil && wsGreetingCache.source == v {
+		return wsGreetingCache.tmpl, nil
+	}
+
+	tmpl, err := template.New("greeting").Option("missingkey=error").Parse(v)
+	if err != nil {
+		return nil, fmt.Errorf("WS_GREETING: %v", err)
+	}
+
+	// Catch unknown fields such as {{.Host}} at startup too
+	if err := tmpl.Execute(&bytes.Buffer{}, wsGreetingData{}); err != nil {
+		return nil, fmt.Errorf("WS_GREETING: %v", err)
+	}
+	wsGreetingCache.source, wsGreetingCache.tmpl = v, tmpl
+	return tmpl, nil
+}
+
+// wsGreeting returns the message sent when a WebSocket connection opens, and
+// whether one should be sent at all.
+//
+// By default it is "Request served by <host>" when sendServerHostname is set,
+// followed by the correlation nonce if there is one. WS_GREETING replaces the
+// first line with its rendered template, and an empty WS_GREETING disables the
+// greeting entirely, so the first message from the server is the first echo.
+func wsGreeting(sendServerHostname bool, nonce string) ([]byte, bool) {
+	var message []byte
+
+	// Validated at startup by validateConfig
+	tmpl, _ := wsGreetingTemplate()
+	if tmpl != nil {
+		if os.Getenv("WS_GREETING") == "" {
+			return nil, false
+		}
+
+		host, err := os.Hostname()
+		if err != nil {
+			host = "unknown"
+		}
+
+		var buf bytes.Buffer
+		tmpl.Execute(&buf, wsGreetingData{Hostname: host}) // nolint:errcheck
+		message = buf.Bytes()
+	} else if sendServerHostname {
+		host, err := os.Hostname()
+		if err == nil {
+			message = []byte(fmt.Sprintf("Request served by %s", host))
+		} else {
+			message = []byte(fmt.Sprintf("Server hostname unknown: %s", err.Error()))
+		}
+	}
+
+	if nonce != "" {
+		if len(message) > 0 {
+			message = append(message, '\n')
+		}
+		message = append(message, fmt.Sprintf("Nonce: %s", nonce)...)
+	}
+
+	return message, true
+}
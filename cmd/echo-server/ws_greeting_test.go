@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestWebSocketGreeting verifies WS_GREETING customizes or disables the WebSocket greeting
+func TestWebSocketGreeting(t *testing.T) {
+	host, _ := os.Hostname()
+
+	tests := []struct {
+		name     string
+		greeting string
+		want     string
+	}{
+		{"Custom greeting", "Welcome to {{.Hostname}}!", "Welcome to " + host + "!"},
+		{"Disabled greeting", "", "echo me"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WS_GREETING", tt.greeting)
+
+			conn, _, err := websocket.DefaultDialer.Dial("ws://localhost:"+testHTTPPort+"/ws", nil)
+			if err != nil {
+				t.Fatalf("failed to connect to WebSocket: %v", err)
+			}
+			defer conn.Close()
+
+			if err := conn.WriteMessage(websocket.TextMessage, []byte("echo me")); err != nil {
+				t.Fatalf("failed to write message: %v", err)
+			}
+
+			// The first message from the server is the greeting, or the echo when disabled
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second)) // nolint:errcheck
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("failed to read message: %v", err)
+			}
+			if string(message) != tt.want {
+				t.Errorf("expected first message %q, got %q", tt.want, message)
+			}
+		})
+	}
+
+	t.Log("TestWebSocketGreeting passed")
+}
+
+// TestWebSocketGreetingValidation verifies that invalid greeting templates are rejected
+func TestWebSocketGreetingValidation(t *testing.T) {
+	for _, v := range []string{"{{.Hostname", "{{.Host}}"} {
+		t.Setenv("WS_GREETING", v)
+		if _, err := wsGreetingTemplate(); err == nil {
+			t.Errorf("expected error for WS_GREETING=%q", v)
+		}
+	}
+
+	t.Log("TestWebSocketGreetingValidation passed")
+}
+
+// TestWebSocketGreetingCache verifies the greeting template is parsed once and reparsed only when WS_GREETING changes
+func TestWebSocketGreetingCache(t *testing.T) {
+	t.Setenv("WS_GREETING", "Hello from {{.Hostname}}")
+	first, err := wsGreetingTemplate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again, _ := wsGreetingTemplate(); again != first {
+		t.Error("expected the parsed template to be reused")
+	}
+
+	t.Setenv("WS_GREETING", "Hi from {{.Hostname}}")
+	if changed, _ := wsGreetingTemplate(); changed == first {
+		t.Error("expected a changed WS_GREETING to be parsed again")
+	}
+
+	t.Log("TestWebSocketGreetingCache passed")
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// Bounds on the WebSocket transcripts kept when WS_TRANSCRIPTS is set.
+const (
+	// maxTranscriptBytes caps the message bytes kept per session; the oldest
+	// messages are dropped beyond it.
+	maxTranscriptBytes = 1 << 20
+
+	// maxTranscriptSessions caps the number of sessions kept; the least
+	// recently active session is dropped beyond it.
+	maxTranscriptSessions = 100
+
+	// transcriptTTL is how long a transcript is kept after the session's
+	// last message.
+	transcriptTTL = 10 * time.Minute
+)
+
+// wsTranscriptEntry is a message of a WebSocket session transcript. Binary
+// messages are base64-encoded.
+type wsTranscriptEntry struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	Type      string    `json:"type"`
+	Data      string    `json:"data"`
+
+	size int
+}
+
+// wsTranscript is the message history of one WebSocket session.
+type wsTranscript struct {
+	entries []wsTranscriptEntry
+	bytes   int
+	updated time.Time
+}
+
+// wsTranscriptStore keeps the transcripts of WebSocket sessions, keyed by
+// the session id the client connected with.
+type wsTranscriptStore struct {
+	mu          sync.Mutex
+	transcripts map[string]*wsTranscript
+}
+
+// wsTranscripts holds the WebSocket transcripts served by
+// /ws-transcript/{sessionId}.
+var wsTranscripts = &wsTranscriptStore{transcripts: map[string]*wsTranscript{}}
+
+// record appends a message sent or received in the session to its
+// transcript.
+func (s *wsTranscriptStore) record(session, direction string, messageType int, data []byte) {
+	entry := wsTranscriptEntry{Time: time.Now().UTC(), Direction: direction, Type: "text", Data: string(data), size: len(data)}
+	if messageType == websocket.BinaryMessage {
+		entry.Type, entry.Data = "binary", base64.StdEncoding.EncodeToString(data)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(entry.Time)
+
+	t, ok := s.transcripts[session]
+	if !ok {
+		// The new transcript is the most recently active, so it is never the
+		// one evicted
+		t = &wsTranscript{updated: entry.Time}
+		s.transcripts[session] = t
+		s.evictOldest()
+	}
+
+	t.entries = append(t.entries, entry)
+	t.bytes += entry.size
+	t.updated = entry.Time
+	for t.bytes > maxTranscriptBytes && len(t.entries) > 1 {
+		t.bytes -= t.entries[0].size
+		t.entries = t.entries[1:]
+	}
+}
+
+// get returns a copy of the session's transcript, if one is kept.
+func (s *wsTranscriptStore) get(session string) ([]wsTranscriptEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(time.Now())
+
+	t, ok := s.transcripts[session]
+	if !ok {
+		return nil, false
+	}
+	return append([]wsTranscriptEntry(nil), t.entries...), true
+}
+
+// prune drops the transcripts whose last message is older than
+// transcriptTTL.
+func (s *wsTranscriptStore) prune(now time.Time) {
+	for session, t := range s.transcripts {
+		if now.Sub(t.updated) > transcriptTTL {
+			delete(s.transcripts, session)
+		}
+	}
+}
+
+// evictOldest drops the least recently active transcripts beyond
+// maxTranscriptSessions.
+func (s *wsTranscriptStore) evictOldest() {
+	for len(s.transcripts) > maxTranscriptSessions {
+		var oldest string
+		for session, t := range s.transcripts {
+			if oldest == "" || t.updated.Before(s.transcripts[oldest].updated) {
+				oldest = session
+			}
+		}
+		delete(s.transcripts, oldest)
+	}
+}
+
+// wsTranscriptHandler handles GET /ws-transcript/{sessionId}, returning the
+// messages sent and received by WebSocket connections that joined the
+// session with the "session" query parameter.
+func wsTranscriptHandler(w http.ResponseWriter, r *http.Request) {
+	session := mux.Vars(r)["sessionId"]
+
+	entries, ok := wsTranscripts.get(session)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No transcript for session %q", session))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"session":  session,
+		"messages": entries,
+	})
+}
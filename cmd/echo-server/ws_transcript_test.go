@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestWebSocketTranscript verifies the messages of a WebSocket session can be fetched afterwards
+func TestWebSocketTranscript(t *testing.T) {
+	t.Setenv("WS_TRANSCRIPTS", "true")
+	t.Setenv("ADMIN_TOKEN", "secret")
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://localhost:"+testHTTPPort+"/ws?session=transcript-1", nil)
+	if err != nil {
+		t.Fatalf("failed to connect to WebSocket: %v", err)
+	}
+
+	// Skip the greeting
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("failed to read greeting: %v", err)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatalf("failed to write message: %v", err)
+	}
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("failed to read echo: %v", err)
+	}
+	conn.Close()
+
+	type transcript struct {
+		Session  string              `json:"session"`
+		Messages []wsTranscriptEntry `json:"messages"`
+	}
+
+	fetch := func(t *testing.T, session, token string) (*http.Response, transcript) {
+		t.Helper()
+
+		req, err := http.NewRequest("GET", httpBaseURL+"/ws-transcript/"+session, nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var tr transcript
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return resp, tr
+	}
+
+	t.Run("Transcript", func(t *testing.T) {
+		// The echo is recorded just after it is written, so allow it a moment
+		var tr transcript
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			var resp *http.Response
+			if resp, tr = fetch(t, "transcript-1", "secret"); resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", resp.StatusCode)
+			}
+			if len(tr.Messages) >= 3 {
+				break
+			}
+		}
+
+		if len(tr.Messages) != 3 {
+			t.Fatalf("expected greeting, message and echo, got %+v", tr.Messages)
+		}
+
+		want := []struct{ direction, data string }{{"received", "hello"}, {"sent", "hello"}}
+		for i, w := range want {
+			got := tr.Messages[i+1]
+			if got.Direction != w.direction || got.Type != "text" || got.Data != w.data {
+				t.Errorf("expected message %d to be %s %q, got %+v", i+1, w.direction, w.data, got)
+			}
+		}
+		if tr.Messages[0].Direction != "sent" {
+			t.Errorf("expected the greeting to be sent first, got %+v", tr.Messages[0])
+		}
+	})
+
+	t.Run("Unknown session", func(t *testing.T) {
+		if resp, _ := fetch(t, "no-such-session", "secret"); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Requires admin token", func(t *testing.T) {
+		if resp, _ := fetch(t, "transcript-1", "wrong"); resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("Size cap", func(t *testing.T) {
+		store := &wsTranscriptStore{transcripts: map[string]*wsTranscript{}}
+		big := make([]byte, maxTranscriptBytes/2+1)
+		for i := 0; i < 3; i++ {
+			store.record("s", "received", websocket.BinaryMessage, big)
+		}
+
+		if entries, _ := store.get("s"); len(entries) != 1 {
+			t.Errorf("expected only the newest message to fit, got %d", len(entries))
+		}
+	})
+
+	t.Run("Session cap", func(t *testing.T) {
+		store := &wsTranscriptStore{transcripts: map[string]*wsTranscript{}}
+		for i := 0; i <= maxTranscriptSessions; i++ {
+			store.record(fmt.Sprintf("s%d", i), "received", websocket.TextMessage, []byte("hello"))
+		}
+
+		if len(store.transcripts) != maxTranscriptSessions {
+			t.Errorf("expected %d sessions, got %d", maxTranscriptSessions, len(store.transcripts))
+		}
+		if _, ok := store.get("s0"); ok {
+			t.Error("expected the least recently active session to be evicted")
+		}
+		newest := fmt.Sprintf("s%d", maxTranscriptSessions)
+		if entries, ok := store.get(newest); !ok || len(entries) != 1 {
+			t.Errorf("expected the newest session to be kept, got %v (stored: %t)", entries, ok)
+		}
+	})
+
+	t.Log("TestWebSocketTranscript passed")
+}
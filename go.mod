@@ -3,15 +3,24 @@ module http-echo
 go 1.24.6
 
 require (
+	github.com/andybalholm/brotli v1.2.0
 	github.com/gorilla/mux v1.8.1
 	github.com/gorilla/websocket v1.5.3
+	github.com/itchyny/gojq v0.12.17
+	github.com/pires/go-proxyproto v0.8.1
+	github.com/quic-go/quic-go v0.59.0
 	golang.org/x/net v0.46.0
+	golang.org/x/sys v0.37.0
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250804133106-a7a43d27e69b
 	google.golang.org/grpc v1.76.0
 	google.golang.org/protobuf v1.36.6
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	golang.org/x/sys v0.37.0 // indirect
+	github.com/itchyny/timefmt-go v0.1.6 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/quic-go/qpack v0.6.0 // indirect
+	golang.org/x/crypto v0.43.0 // indirect
 	golang.org/x/text v0.30.0 // indirect
-	google.golang.org/genproto/googleapis/rpc v0.0.0-20250804133106-a7a43d27e69b // indirect
 )